@@ -0,0 +1,144 @@
+// yfig加密属性值的命令行工具
+//
+//	yfig keygen                          生成新的密钥
+//	yfig encrypt [-keyring file] [value] 加密value（为空时读取标准输入）
+//	yfig decrypt [-keyring file] [value] 解密value（为空时读取标准输入）
+//	yfig rotate [-keyring file] [-w] file...
+//	                                     使用密钥环中的第一个密钥重新加密文件中的ENC[...]，-w时写回文件
+//
+// 未指定-keyring时从环境变量YFIG_KEYRING或YFIG_KEYRING_FILE指定的文件读取密钥环
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ydx1011/yfig"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "keygen":
+		err = keygen()
+	case "encrypt", "decrypt":
+		err = crypt(os.Args[1], os.Args[2:])
+	case "rotate":
+		err = rotate(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "yfig:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage:
+  yfig keygen
+  yfig encrypt [-keyring file] [value]
+  yfig decrypt [-keyring file] [value]
+  yfig rotate [-keyring file] [-w] file...`)
+}
+
+func keygen() error {
+	key, err := yfig.GenerateKey()
+	if err != nil {
+		return err
+	}
+	fmt.Println(key)
+	return nil
+}
+
+func loadKeyring(file string) (*yfig.Keyring, error) {
+	if file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		return yfig.ParseKeyring(string(b))
+	}
+	k, err := yfig.LoadKeyring()
+	if err == nil && k == nil {
+		err = fmt.Errorf("no keyring, use -keyring or set %s or %s", yfig.KeyringEnv, yfig.KeyringFileEnv)
+	}
+	return k, err
+}
+
+func crypt(cmd string, args []string) error {
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	keyringFile := fs.String("keyring", "", "keyring file")
+	fs.Parse(args)
+
+	k, err := loadKeyring(*keyringFile)
+	if err != nil {
+		return err
+	}
+	value := strings.Join(fs.Args(), " ")
+	if fs.NArg() == 0 {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		value = strings.TrimRight(string(b), "\r\n")
+	}
+
+	var ret string
+	if cmd == "encrypt" {
+		ret, err = k.Encrypt(value)
+	} else {
+		ret, err = k.Decrypt(value)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(ret)
+	return nil
+}
+
+func rotate(args []string) error {
+	fs := flag.NewFlagSet("rotate", flag.ExitOnError)
+	keyringFile := fs.String("keyring", "", "keyring file")
+	write := fs.Bool("w", false, "write result to file instead of stdout")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		return errors.New("no file to rotate")
+	}
+	k, err := loadKeyring(*keyringFile)
+	if err != nil {
+		return err
+	}
+	for _, name := range fs.Args() {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		ret, err := k.Rotate(string(b))
+		if err != nil {
+			return fmt.Errorf("%s: %s", name, err.Error())
+		}
+		if !*write {
+			fmt.Print(ret)
+			continue
+		}
+		fi, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(name, []byte(ret), fi.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,444 @@
+package yfig
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 字符串转换时间时依次尝试的格式
+var TimeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// 转换规则：
+// string: 原样返回
+// bool/整数/浮点: 按十进制格式化（整数值的浮点不带小数和指数）
+// 其他类型返回错误
+func toString(v interface{}) (string, error) {
+	switch o := v.(type) {
+	case string:
+		return o, nil
+	case []byte:
+		return string(o), nil
+	case bool:
+		return strconv.FormatBool(o), nil
+	case float64:
+		return formatFloat(o, 64), nil
+	case float32:
+		return formatFloat(float64(o), 32), nil
+	case int, int8, int16, int32, int64:
+		return fmt.Sprintf("%d", o), nil
+	case uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", o), nil
+	case nil:
+		return "", nil
+	}
+	return "", fmt.Errorf("cannot convert %T to string", v)
+}
+
+func formatFloat(f float64, bitSize int) string {
+	if f == math.Trunc(f) && math.Abs(f) < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, bitSize)
+	}
+	return strconv.FormatFloat(f, 'g', -1, bitSize)
+}
+
+// 转换规则：
+// bool: 原样返回
+// 数字: 非0为true
+// string: 支持strconv.ParseBool的格式以及yes/no、on/off（不区分大小写）
+func toBool(v interface{}) (bool, error) {
+	switch o := v.(type) {
+	case bool:
+		return o, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(o)) {
+		case "yes", "y", "on":
+			return true, nil
+		case "no", "n", "off":
+			return false, nil
+		}
+		b, err := strconv.ParseBool(strings.TrimSpace(o))
+		if err != nil {
+			return false, fmt.Errorf("cannot convert %q to bool", o)
+		}
+		return b, nil
+	}
+	if f, ok := numberOf(v); ok {
+		return f != 0, nil
+	}
+	return false, fmt.Errorf("cannot convert %T to bool", v)
+}
+
+// 转换规则：
+// 整数: 原样返回
+// 浮点: 必须为整数值
+// string: strconv.ParseInt(s, 0, 64)，支持0x、0o、0b前缀；失败时尝试按浮点解析并要求为整数值
+// bitSize用于范围检查
+func toInt64(v interface{}, bitSize int) (int64, error) {
+	var ret int64
+	switch o := v.(type) {
+	case int:
+		ret = int64(o)
+	case int8:
+		ret = int64(o)
+	case int16:
+		ret = int64(o)
+	case int32:
+		ret = int64(o)
+	case int64:
+		ret = o
+	case uint, uint8, uint16, uint32, uint64:
+		u, _ := toUint64(o, 64)
+		if u > math.MaxInt64 {
+			return 0, fmt.Errorf("value %d overflows int%d", u, bitSize)
+		}
+		ret = int64(u)
+	case float32, float64:
+		f, _ := numberOf(o)
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, fmt.Errorf("cannot convert %v to int%d", f, bitSize)
+		}
+		ret = int64(f)
+	case string:
+		s := strings.TrimSpace(o)
+		i, err := strconv.ParseInt(s, 0, 64)
+		if err != nil {
+			f, ferr := strconv.ParseFloat(s, 64)
+			if ferr != nil || f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+				return 0, fmt.Errorf("cannot convert %q to int%d", o, bitSize)
+			}
+			i = int64(f)
+		}
+		ret = i
+	default:
+		return 0, fmt.Errorf("cannot convert %T to int%d", v, bitSize)
+	}
+	if bitSize < 64 {
+		limit := int64(1) << (bitSize - 1)
+		if ret < -limit || ret >= limit {
+			return 0, fmt.Errorf("value %d overflows int%d", ret, bitSize)
+		}
+	}
+	return ret, nil
+}
+
+// 转换规则同toInt64，负数返回错误
+func toUint64(v interface{}, bitSize int) (uint64, error) {
+	var ret uint64
+	switch o := v.(type) {
+	case uint:
+		ret = uint64(o)
+	case uint8:
+		ret = uint64(o)
+	case uint16:
+		ret = uint64(o)
+	case uint32:
+		ret = uint64(o)
+	case uint64:
+		ret = o
+	case float32, float64:
+		f, _ := numberOf(o)
+		if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 {
+			return 0, fmt.Errorf("cannot convert %v to uint%d", f, bitSize)
+		}
+		ret = uint64(f)
+	case string:
+		s := strings.TrimSpace(o)
+		u, err := strconv.ParseUint(s, 0, 64)
+		if err != nil {
+			f, ferr := strconv.ParseFloat(s, 64)
+			if ferr != nil || f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 {
+				return 0, fmt.Errorf("cannot convert %q to uint%d", o, bitSize)
+			}
+			u = uint64(f)
+		}
+		ret = u
+	default:
+		i, err := toInt64(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("cannot convert %T to uint%d", v, bitSize)
+		}
+		if i < 0 {
+			return 0, fmt.Errorf("cannot convert negative value %d to uint%d", i, bitSize)
+		}
+		ret = uint64(i)
+	}
+	if bitSize < 64 && ret >= uint64(1)<<bitSize {
+		return 0, fmt.Errorf("value %d overflows uint%d", ret, bitSize)
+	}
+	return ret, nil
+}
+
+// 转换规则：
+// 数字: 原样返回（float32会检查范围）
+// string: strconv.ParseFloat
+func toFloat64(v interface{}, bitSize int) (float64, error) {
+	var ret float64
+	if f, ok := numberOf(v); ok {
+		ret = f
+	} else if s, ok := v.(string); ok {
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return 0, fmt.Errorf("cannot convert %q to float%d", s, bitSize)
+		}
+		ret = f
+	} else {
+		return 0, fmt.Errorf("cannot convert %T to float%d", v, bitSize)
+	}
+	if bitSize == 32 && !math.IsInf(ret, 0) && math.Abs(ret) > math.MaxFloat32 {
+		return 0, fmt.Errorf("value %v overflows float32", ret)
+	}
+	return ret, nil
+}
+
+// 转换规则：
+// 数字: 按纳秒处理（与time.Duration一致）
+// string: time.ParseDuration，纯数字字符串按纳秒处理
+func toDuration(v interface{}) (time.Duration, error) {
+	if s, ok := v.(string); ok {
+		s = strings.TrimSpace(s)
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return time.Duration(i), nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("cannot convert %q to time.Duration", s)
+		}
+		return d, nil
+	}
+	if d, ok := v.(time.Duration); ok {
+		return d, nil
+	}
+	i, err := toInt64(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot convert %T to time.Duration", v)
+	}
+	return time.Duration(i), nil
+}
+
+// 转换规则：
+// time.Time: 原样返回
+// 数字: unix时间戳（秒）
+// string: 依次使用TimeLayouts解析
+func toTime(v interface{}) (time.Time, error) {
+	switch o := v.(type) {
+	case time.Time:
+		return o, nil
+	case string:
+		s := strings.TrimSpace(o)
+		for _, layout := range TimeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("cannot convert %q to time.Time", o)
+	}
+	i, err := toInt64(v, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot convert %T to time.Time", v)
+	}
+	return time.Unix(i, 0), nil
+}
+
+// 转换规则：
+// 列表: 每个元素按toString转换
+// string: 按","分割并去除首尾空白，空字符串返回空列表
+func toStringSlice(v interface{}) ([]string, error) {
+	switch o := v.(type) {
+	case []string:
+		return o, nil
+	case []interface{}:
+		ret := make([]string, 0, len(o))
+		for i := range o {
+			s, err := toString(o[i])
+			if err != nil {
+				return nil, fmt.Errorf("index %d: %s", i, err.Error())
+			}
+			ret = append(ret, s)
+		}
+		return ret, nil
+	case string:
+		if strings.TrimSpace(o) == "" {
+			return []string{}, nil
+		}
+		ret := strings.Split(o, ",")
+		for i := range ret {
+			ret[i] = strings.TrimSpace(ret[i])
+		}
+		return ret, nil
+	}
+	return nil, fmt.Errorf("cannot convert %T to []string", v)
+}
+
+// 转换规则：只接受map类型，非string的key按fmt格式化
+func toStringMap(v interface{}) (map[string]interface{}, error) {
+	switch o := v.(type) {
+	case map[string]interface{}:
+		return o, nil
+	case map[interface{}]interface{}:
+		ret := make(map[string]interface{}, len(o))
+		for k, v := range o {
+			ret[fmt.Sprintf("%v", k)] = v
+		}
+		return ret, nil
+	}
+	return nil, fmt.Errorf("cannot convert %T to map[string]interface{}", v)
+}
+
+func numberOf(v interface{}) (float64, bool) {
+	switch o := v.(type) {
+	case float64:
+		return o, true
+	case float32:
+		return float64(o), true
+	case int:
+		return float64(o), true
+	case int8:
+		return float64(o), true
+	case int16:
+		return float64(o), true
+	case int32:
+		return float64(o), true
+	case int64:
+		return float64(o), true
+	case uint:
+		return float64(o), true
+	case uint8:
+		return float64(o), true
+	case uint16:
+		return float64(o), true
+	case uint32:
+		return float64(o), true
+	case uint64:
+		return float64(o), true
+	}
+	return 0, false
+}
+
+// 将环境变量、命令行等字符串形式的值解析为bool或数字，无法解析时原样返回string
+// 只识别true/false和十进制数字，避免"0123"、"yes"等内容被意外转换
+func parseScalar(s string) interface{} {
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if s == "" || strings.TrimSpace(s) != s {
+		return s
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && c != '-' && c != '+' && c != '.' && c != 'e' && c != 'E' {
+			return s
+		}
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if strconv.FormatInt(i, 10) == s {
+			return float64(i)
+		}
+		return s
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		if s[0] != '0' || strings.HasPrefix(s, "0.") {
+			return f
+		}
+	}
+	return s
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// 将字符串（如tag中的default、环境变量）转换后赋值给v
+// time.Time在layout不为空时按layout解析，注册了DecodeHook（或实现了encoding.TextUnmarshaler）的类型使用转换方法
+// string、bool、数字使用上述转换规则，slice按sep（为空时使用","）分割后逐个转换，指针分配后转换，其他类型使用loader反序列化
+func setFromString(v reflect.Value, s string, sep, layout string, loader ValueLoader) error {
+	t := v.Type()
+	if t == timeType && layout != "" {
+		tm, err := time.Parse(layout, strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(tm))
+		return nil
+	}
+	if _, ok := lookupDecodeHook(t); ok || (t.Kind() != reflect.Ptr && reflect.PtrTo(t).Implements(textUnmarshalerType)) {
+		return decodeRaw(v, s, loader)
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		e := reflect.New(t.Elem())
+		if err := setFromString(e.Elem(), s, sep, layout, loader); err != nil {
+			return err
+		}
+		v.Set(e)
+		return nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte(s))
+			return nil
+		}
+		if sep == "" {
+			sep = ","
+		}
+		var parts []string
+		if strings.TrimSpace(s) != "" {
+			parts = strings.Split(s, sep)
+		}
+		ret := reflect.MakeSlice(t, len(parts), len(parts))
+		for i := range parts {
+			if err := setFromString(ret.Index(i), strings.TrimSpace(parts[i]), "", layout, loader); err != nil {
+				return err
+			}
+		}
+		v.Set(ret)
+		return nil
+	case reflect.String:
+		v.SetString(s)
+		return nil
+	case reflect.Bool:
+		b, err := toBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := toInt64(s, t.Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := toUint64(s, t.Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat64(s, t.Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+		return nil
+	}
+
+	c := reflect.New(t)
+	if err := loader.Deserialize(s, c.Interface()); err != nil {
+		return err
+	}
+	v.Set(c.Elem())
+	return nil
+}
@@ -0,0 +1,391 @@
+package yfig
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 将属性的原始值（ValueLoader反序列化到interface{}的结果，如string、float64、[]interface{}）转换为目标类型
+// 返回值必须可以赋值给注册的类型
+type DecodeHook func(raw interface{}) (interface{}, error)
+
+var (
+	decodeHooks     = map[reflect.Type]DecodeHook{}
+	decodeHooksLock sync.RWMutex
+
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+func init() {
+	RegisterDecodeHook(durationType, func(raw interface{}) (interface{}, error) {
+		return toDuration(raw)
+	})
+	RegisterDecodeHook(timeType, func(raw interface{}) (interface{}, error) {
+		return toTime(raw)
+	})
+	RegisterDecodeHook(reflect.TypeOf(ByteSize(0)), func(raw interface{}) (interface{}, error) {
+		return toByteSize(raw)
+	})
+	RegisterDecodeHook(reflect.TypeOf(&url.URL{}), func(raw interface{}) (interface{}, error) {
+		s, err := toString(raw)
+		if err != nil {
+			return nil, err
+		}
+		return url.Parse(s)
+	})
+	RegisterDecodeHook(reflect.TypeOf(url.URL{}), func(raw interface{}) (interface{}, error) {
+		s, err := toString(raw)
+		if err != nil {
+			return nil, err
+		}
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		return *u, nil
+	})
+	RegisterDecodeHook(reflect.TypeOf(net.IP{}), func(raw interface{}) (interface{}, error) {
+		s, err := toString(raw)
+		if err != nil {
+			return nil, err
+		}
+		ip := net.ParseIP(strings.TrimSpace(s))
+		if ip == nil {
+			return nil, fmt.Errorf("cannot convert %q to net.IP", s)
+		}
+		return ip, nil
+	})
+	RegisterDecodeHook(reflect.TypeOf(&regexp.Regexp{}), func(raw interface{}) (interface{}, error) {
+		s, err := toString(raw)
+		if err != nil {
+			return nil, err
+		}
+		return regexp.Compile(s)
+	})
+}
+
+// 注册类型转换方法，GetValue及Fill系列方法填充该类型时使用，同一类型重复注册时替换
+// 未注册转换方法且实现了encoding.TextUnmarshaler的类型，属性值为字符串时使用UnmarshalText
+// param: t 目标类型
+// param: hook 转换方法，为nil时删除已注册的方法
+func RegisterDecodeHook(t reflect.Type, hook DecodeHook) {
+	decodeHooksLock.Lock()
+	defer decodeHooksLock.Unlock()
+
+	if hook == nil {
+		delete(decodeHooks, t)
+		return
+	}
+	decodeHooks[t] = hook
+}
+
+func lookupDecodeHook(t reflect.Type) (DecodeHook, bool) {
+	decodeHooksLock.RLock()
+	defer decodeHooksLock.RUnlock()
+
+	hook, ok := decodeHooks[t]
+	return hook, ok
+}
+
+// t或其元素（slice、array、map的值、指针、struct的字段）是否需要使用转换方法
+func hasDecodeHook(t reflect.Type) bool {
+	return elemHasDecodeHook(t, map[reflect.Type]bool{})
+}
+
+// param: visited 已检查的类型，避免递归类型（如type T []T）无限递归
+func elemHasDecodeHook(t reflect.Type, visited map[reflect.Type]bool) bool {
+	if visited[t] {
+		return false
+	}
+	visited[t] = true
+	if _, ok := lookupDecodeHook(t); ok {
+		return true
+	}
+	if t.Kind() != reflect.Ptr && reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return elemHasDecodeHook(t.Elem(), visited)
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && elemHasDecodeHook(t.Elem(), visited)
+	case reflect.Struct:
+		// 自定义了UnmarshalJSON的struct由ValueLoader处理
+		if reflect.PtrTo(t).Implements(jsonUnmarshalerType) {
+			return false
+		}
+		for _, f := range decodeFields(t) {
+			if elemHasDecodeHook(f.typ, visited) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type decodeField struct {
+	// 属性中的key，与ValueLoader（json tag）一致，匹配时不区分大小写
+	name  string
+	index []int
+	typ   reflect.Type
+}
+
+// 与encoding/json相同规则的struct字段：使用json tag中的名称，忽略"-"及未导出的字段，展开匿名嵌入且没有名称的struct
+// 匿名嵌入的struct指针不展开，由ValueLoader处理
+func decodeFields(t reflect.Type) []decodeField {
+	var ret []decodeField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := tag
+		if i := strings.IndexByte(tag, ','); i != -1 {
+			name = tag[:i]
+		}
+		if field.Anonymous && name == "" {
+			if field.Type.Kind() == reflect.Struct {
+				for _, f := range decodeFields(field.Type) {
+					f.index = append([]int{i}, f.index...)
+					ret = append(ret, f)
+				}
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		ret = append(ret, decodeField{name: name, index: []int{i}, typ: field.Type})
+	}
+	return ret
+}
+
+// 使用转换方法将原始值赋值给v，不需要转换的部分通过loader序列化后反序列化
+func decodeRaw(v reflect.Value, raw interface{}, loader ValueLoader) error {
+	t := v.Type()
+	if hook, ok := lookupDecodeHook(t); ok {
+		ret, err := hook(raw)
+		if err != nil {
+			return err
+		}
+		rv := reflect.ValueOf(ret)
+		if !rv.IsValid() {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		if !rv.Type().AssignableTo(t) {
+			return fmt.Errorf("decode hook of %s returns %T", t.String(), ret)
+		}
+		v.Set(rv)
+		return nil
+	}
+	if s, ok := raw.(string); ok && t.Kind() != reflect.Ptr && reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	if raw != nil && hasDecodeHook(t) {
+		switch t.Kind() {
+		case reflect.Ptr:
+			e := reflect.New(t.Elem())
+			if err := decodeRaw(e.Elem(), raw, loader); err != nil {
+				return err
+			}
+			v.Set(e)
+			return nil
+		case reflect.Slice, reflect.Array:
+			list, ok := raw.([]interface{})
+			if !ok {
+				return fmt.Errorf("cannot convert %T to %s", raw, t.String())
+			}
+			ret := reflect.New(t).Elem()
+			if t.Kind() == reflect.Slice {
+				ret = reflect.MakeSlice(t, len(list), len(list))
+			}
+			for i := 0; i < len(list) && i < ret.Len(); i++ {
+				if err := decodeRaw(ret.Index(i), list[i], loader); err != nil {
+					return fmt.Errorf("index %d: %s", i, err.Error())
+				}
+			}
+			v.Set(ret)
+			return nil
+		case reflect.Map:
+			m, ok := raw.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("cannot convert %T to %s", raw, t.String())
+			}
+			ret := reflect.MakeMapWithSize(t, len(m))
+			for k, e := range m {
+				ev := reflect.New(t.Elem()).Elem()
+				if err := decodeRaw(ev, e, loader); err != nil {
+					return fmt.Errorf("key %s: %s", k, err.Error())
+				}
+				ret.SetMapIndex(reflect.ValueOf(k).Convert(t.Key()), ev)
+			}
+			v.Set(ret)
+			return nil
+		case reflect.Struct:
+			m, ok := raw.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("cannot convert %T to %s", raw, t.String())
+			}
+			return decodeStruct(v, m, loader)
+		}
+	}
+
+	data, err := loader.Serialize(raw)
+	if err != nil {
+		return err
+	}
+	c := reflect.New(t)
+	if err := loader.Deserialize(data, c.Interface()); err != nil {
+		return err
+	}
+	v.Set(c.Elem())
+	return nil
+}
+
+// 需要转换的字段逐个使用decodeRaw，其余的key通过loader序列化后反序列化
+func decodeStruct(v reflect.Value, m map[string]interface{}, loader ValueLoader) error {
+	t := v.Type()
+	rest := make(map[string]interface{}, len(m))
+	for k, e := range m {
+		rest[k] = e
+	}
+	type hooked struct {
+		field decodeField
+		raw   interface{}
+	}
+	var fields []hooked
+	for _, f := range decodeFields(t) {
+		if !hasDecodeHook(f.typ) {
+			continue
+		}
+		e, ok := m[f.name]
+		for _, k := range sortedKeys(m) {
+			if strings.EqualFold(k, f.name) {
+				if !ok {
+					e, ok = m[k], true
+				}
+				delete(rest, k)
+			}
+		}
+		if ok {
+			fields = append(fields, hooked{field: f, raw: e})
+		}
+	}
+
+	ret := reflect.New(t)
+	if len(rest) > 0 {
+		data, err := loader.Serialize(rest)
+		if err != nil {
+			return err
+		}
+		if err := loader.Deserialize(data, ret.Interface()); err != nil {
+			return err
+		}
+	}
+	for _, f := range fields {
+		if err := decodeRaw(ret.Elem().FieldByIndex(f.field.index), f.raw, loader); err != nil {
+			return fmt.Errorf("field %s: %s", f.field.name, err.Error())
+		}
+	}
+	v.Set(ret.Elem())
+	return nil
+}
+
+// 字节数，支持"1024"、"10KB"、"10MiB"、"1.5G"等格式
+// KB、MB、GB、TB、PB按1000换算；KiB、MiB等及单字母K、M、G、T、P按1024换算；不区分大小写
+type ByteSize uint64
+
+const (
+	KiB ByteSize = 1 << (10 * (iota + 1))
+	MiB
+	GiB
+	TiB
+	PiB
+)
+
+var byteSizeUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"pb":  1e15,
+	"k":   float64(KiB),
+	"m":   float64(MiB),
+	"g":   float64(GiB),
+	"t":   float64(TiB),
+	"p":   float64(PiB),
+	"kib": float64(KiB),
+	"mib": float64(MiB),
+	"gib": float64(GiB),
+	"tib": float64(TiB),
+	"pib": float64(PiB),
+}
+
+// param: s 字节数字符串
+// return: 字节数，格式错误或超出范围返回错误
+func ParseByteSize(s string) (ByteSize, error) {
+	str := strings.TrimSpace(s)
+	i := 0
+	for i < len(str) && (str[i] >= '0' && str[i] <= '9' || str[i] == '.') {
+		i++
+	}
+	n, err := strconv.ParseFloat(str[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot convert %q to ByteSize", s)
+	}
+	unit, ok := byteSizeUnits[strings.ToLower(strings.TrimSpace(str[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("cannot convert %q to ByteSize: unknown unit", s)
+	}
+	n = math.Round(n * unit)
+	if n >= math.MaxUint64 {
+		return 0, fmt.Errorf("value %q overflows ByteSize", s)
+	}
+	return ByteSize(n), nil
+}
+
+// 能被1024整除时使用最大的二进制单位，如"10MiB"，否则为字节数
+func (b ByteSize) String() string {
+	units := []struct {
+		size ByteSize
+		name string
+	}{{PiB, "PiB"}, {TiB, "TiB"}, {GiB, "GiB"}, {MiB, "MiB"}, {KiB, "KiB"}}
+	for _, u := range units {
+		if b >= u.size && b%u.size == 0 {
+			return fmt.Sprintf("%d%s", b/u.size, u.name)
+		}
+	}
+	return strconv.FormatUint(uint64(b), 10)
+}
+
+// 转换规则：
+// 数字: 字节数
+// string: ParseByteSize
+func toByteSize(v interface{}) (ByteSize, error) {
+	if s, ok := v.(string); ok {
+		return ParseByteSize(s)
+	}
+	u, err := toUint64(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot convert %T to ByteSize", v)
+	}
+	return ByteSize(u), nil
+}
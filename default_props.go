@@ -10,20 +10,67 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"text/template"
 )
 
 type Opt func(ctx *DefaultProperties) error
 
 type DefaultProperties struct {
+	// 创建时WithValue指定的属性值，仅为兼容保留：读取请使用AllSettings、Snapshot，修改请使用ReadValue、Set
+	//
+	// Deprecated: 属性值保存在内部的不可变状态中，直接赋值不会生效；
+	// 创建后不再更新，ReadValue、Set等方法的修改不会反映到该字段
 	Value *Value
 	Env   map[string]string
 
-	reader ValueReader
-	loader ValueLoader
+	reader      ValueReader
+	loader      ValueLoader
+	overlays    []OverlaySource
+	dotenvFiles []string
+	// SetDefault设置的默认值，每次重新加载后应用
+	defaults []defaultValue
+	// ReadValue读取的原始内容（已执行模板），WriteTo使用
+	source  []byte
+	secrets *secretResolver
+	keyring lazyKeyring
+	// 当前属性值中解密过的属性，属性名 -> 加密值，每次加载时整体替换，与propState共享，替换后不再修改
+	encrypted map[string]encryptedValue
+	// WithTemplateFuncs添加的模板函数
+	funcs template.FuncMap
+	// WithReferences启用${key}引用
+	refs bool
+
+	// 当前的*propState，读取属性时不加锁
+	state atomic.Value
+	// 保护Value、Env的修改
+	lock sync.RWMutex
+
+	subs     []*subscriber
+	subsLock sync.Mutex
+}
 
-	cache map[string]interface{}
-	lock  sync.RWMutex
+// 属性值及其缓存，创建后属性值不再修改，ReadValue等方法创建新的propState并原子替换
+type propState struct {
+	value *Value
+	// 每次替换属性值时递增
+	version uint64
+	// 属性值的sha256，第一次使用时计算
+	hash     string
+	hashOnce sync.Once
+	// Get使用的缓存，key -> string
+	cache sync.Map
+	// GetValue使用的缓存，key -> ValueLoader序列化后的内容
+	valueCache sync.Map
+	// 解析${secret:...}引用，包含引用的属性不缓存
+	secrets    *secretResolver
+	hasSecrets bool
+	// 是否解析${key}引用及是否包含引用
+	refs     bool
+	hasRefs  bool
+	scanOnce sync.Once
+	// 解密的属性，创建后不再修改
+	encrypted map[string]encryptedValue
 }
 
 func New(opts ...Opt) *DefaultProperties {
@@ -31,8 +78,9 @@ func New(opts ...Opt) *DefaultProperties {
 		Value:  nil,
 		reader: NewYamlReader(),
 		loader: NewYamlLoader(),
-		cache:  map[string]interface{}{},
 	}
+	ret.secrets = newSecretResolver(ret)
+	ret.state.Store(&propState{secrets: ret.secrets})
 
 	for _, opt := range opts {
 		err := opt(ret)
@@ -45,6 +93,23 @@ func New(opts ...Opt) *DefaultProperties {
 	return ret
 }
 
+// 初始属性值
+func WithValue(v Value) Opt {
+	return func(ctx *DefaultProperties) error {
+		ctx.Value = &v
+		ctx.state.Store(&propState{value: &v, version: 1, secrets: ctx.secrets, refs: ctx.refs})
+		return nil
+	}
+}
+
+// 添加覆盖数据源，每次ReadValue后按添加顺序覆盖读取的属性值
+func WithOverlay(s OverlaySource) Opt {
+	return func(ctx *DefaultProperties) error {
+		ctx.overlays = append(ctx.overlays, s)
+		return nil
+	}
+}
+
 func (ctx *DefaultProperties) SetValueReader(r ValueReader) {
 	ctx.reader = r
 }
@@ -53,28 +118,139 @@ func (ctx *DefaultProperties) SetValueLoader(l ValueLoader) {
 	ctx.loader = l
 }
 
+func (ctx *DefaultProperties) getLoader() ValueLoader {
+	return ctx.loader
+}
+
+// 先查找Env（包含dotenv文件中的变量），再查找进程环境变量
+func (ctx *DefaultProperties) lookupEnv(name string) (string, bool) {
+	ctx.lock.RLock()
+	v, ok := ctx.Env[name]
+	ctx.lock.RUnlock()
+	if ok {
+		return v, true
+	}
+	return os.LookupEnv(name)
+}
+
 func (ctx *DefaultProperties) ReadValue(r io.Reader) error {
+	if ctx.reader == nil {
+		return nil
+	}
+	err := ctx.refreshEnv()
+	if err != nil {
+		return err
+	}
+	v, source, err := ctx.parseSource(r, ctx.reader)
+	if err != nil {
+		return err
+	}
+	err = ctx.applyOverlays(v, nil)
+	if err != nil {
+		return err
+	}
+	ctx.applyDefaults(v, nil)
+	decrypted, encrypted, err := ctx.decryptValue(*v)
+	if err != nil {
+		return err
+	}
+	if err := ctx.checkRefs(&decrypted); err != nil {
+		return err
+	}
+
 	ctx.lock.Lock()
-	defer ctx.lock.Unlock()
+	ctx.source = source
+	ctx.encrypted = encrypted
+	old := ctx.storeLocked(&decrypted)
+	ctx.lock.Unlock()
+
+	ctx.notify(old, &decrypted)
+	return nil
+}
+
+// 使用当前环境变量执行模板后通过reader解析属性值
+func (ctx *DefaultProperties) parseValue(r io.Reader, reader ValueReader) (*Value, error) {
+	v, _, err := ctx.parseSource(r, reader)
+	return v, err
+}
 
-	ctx.cache = map[string]interface{}{}
-	ctx.Env = GetEnvs()
+// return: 属性值，执行模板后的内容
+func (ctx *DefaultProperties) parseSource(r io.Reader, reader ValueReader) (*Value, []byte, error) {
+	r, err := ctx.ExecTemplate(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	v, err := reader.Read(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	return v, data, nil
+}
 
-	if ctx.reader != nil {
-		r, err := ctx.ExecTemplate(r)
+// onSet不为nil时在每个被覆盖的key上调用
+func (ctx *DefaultProperties) applyOverlays(v *Value, onSet func(name, key string, replaced bool)) error {
+	if *v == nil {
+		*v = Value{}
+	}
+	for _, s := range ctx.overlays {
+		o, err := s.Overlay(ctx, *v)
 		if err != nil {
-			return err
+			return fmt.Errorf("overlay %s failed: %s", s.Name(), err.Error())
 		}
-		v, err := ctx.reader.Read(r)
-		if err != nil {
-			return err
+		if o == nil {
+			continue
 		}
+		name := s.Name()
+		mergeValue(*v, *o, "", func(key string, replaced bool) {
+			if onSet != nil {
+				onSet(name, key, replaced)
+			}
+		})
+	}
+	return nil
+}
 
-		ctx.Value = v
+// 重新读取环境变量及dotenv文件
+func (ctx *DefaultProperties) refreshEnv() error {
+	env := GetEnvs()
+	if err := mergeDotenv(env, ctx.dotenvFiles); err != nil {
+		return err
 	}
+
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+
+	ctx.Env = env
 	return nil
 }
 
+// 替换属性值（使用新的缓存）并通知订阅者
+// param: encrypted v中解密的属性
+func (ctx *DefaultProperties) setValue(v *Value, encrypted map[string]encryptedValue) {
+	ctx.lock.Lock()
+	ctx.encrypted = encrypted
+	old := ctx.storeLocked(v)
+	ctx.lock.Unlock()
+
+	ctx.notify(old, v)
+}
+
+// 需持有lock
+// return: 替换前的属性值
+func (ctx *DefaultProperties) storeLocked(v *Value) *Value {
+	cur := ctx.current()
+	ctx.state.Store(&propState{value: v, version: cur.version + 1, secrets: ctx.secrets, refs: ctx.refs, encrypted: ctx.encrypted})
+	return cur.value
+}
+
+func (ctx *DefaultProperties) current() *propState {
+	return ctx.state.Load().(*propState)
+}
+
 func GetEnvs() map[string]string {
 	s := os.Environ()
 	ret := map[string]string{}
@@ -99,9 +275,7 @@ func (ctx *DefaultProperties) ExecTemplate(r io.Reader) (io.Reader, error) {
 		return nil, err
 	}
 	// 替换生产环境下config-prod.yml中env的值
-	tpl, ok := template.New("").Option("missingkey=error").Funcs(template.FuncMap{
-		"env": ctx.getEnvValue,
-	}).Parse(buf.String())
+	tpl, ok := template.New("").Option("missingkey=error").Funcs(ctx.templateFuncs()).Parse(buf.String())
 	if ok != nil {
 		logf("parse error")
 		return nil, ok
@@ -130,74 +304,81 @@ func (ctx *DefaultProperties) getEnvValue(key string, arg ...reflect.Value) (ref
 	}
 }
 
-// A.B.C
+// 属性路径格式见parsePath，如A.B.C、servers[0].host、"a.b".c
+// 属性不存在或值为null时返回defaultValue，非字符串的值使用fmt.Sprint格式化
 func (ctx *DefaultProperties) Get(key string, defaultValue string) string {
-	//if key == "" {
-	//	return defaultValue
-	//}
+	return ctx.current().get(key, defaultValue)
+}
 
-	ctx.lock.Lock()
-	defer ctx.lock.Unlock()
+// 基础类型、interface{}、map[string]interface{}、[]interface{}直接赋值，
+// 注册了DecodeHook的类型使用转换方法，其他类型依赖于ValueLoader的序列化和反序列化方式
+func (ctx *DefaultProperties) GetValue(key string, result interface{}) error {
+	return ctx.current().getValue(key, result, ctx.loader)
+}
 
-	if v, ok := ctx.cache[key]; ok {
-		if ret, ok := v.(string); ok {
-			return ret
-		}
+func (s *propState) get(key string, defaultValue string) string {
+	if v, ok := s.cache.Load(key); ok {
+		return v.(string)
 	}
 
-	tempKey := "{{ ." + key + "}}"
-	tpl, ok := template.New("").Option("missingkey=error").Parse(tempKey)
-	if ok != nil {
-		logf("key: %s not found(parse error)", key)
+	node, err := lookupPath(s.value, key)
+	if err != nil || node == nil {
 		return defaultValue
 	}
-	b := strings.Builder{}
-	err := tpl.Execute(&b, ctx.Value)
+	node, dynamic, err := s.resolve(key, node)
 	if err != nil {
+		logf("key: %s %s\n", key, err.Error())
 		return defaultValue
 	}
-
-	ret := b.String()
-	ctx.cache[key] = ret
+	ret, ok := node.(string)
+	if !ok {
+		ret = fmt.Sprint(node)
+	}
+	if !dynamic {
+		s.cache.Store(key, ret)
+	}
 	return ret
 }
 
-// 依赖于ValueReader的序列化和反序列化方式
-func (ctx *DefaultProperties) GetValue(key string, result interface{}) error {
-	//if key == "" {
-	//	return fmt.Errorf("key is empty")
-	//}
-	ctx.lock.Lock()
-	defer ctx.lock.Unlock()
+func (s *propState) getValue(key string, result interface{}, loader ValueLoader) error {
+	node, err := lookupPath(s.value, key)
+	if err != nil {
+		return err
+	}
+	node, dynamic, err := s.resolve(key, node)
+	if err != nil {
+		return fmt.Errorf("key: %s %s", key, err.Error())
+	}
 
-	if v, ok := ctx.cache[key]; ok {
-		if ret, ok := v.(string); ok {
-			err := ctx.loader.Deserialize(ret, result)
+	v := reflect.ValueOf(result)
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		t := v.Elem().Type()
+		if hasDecodeHook(t) {
+			return decodeRaw(v.Elem(), normalizeValue(node), loader)
+		}
+		if ok, err := assignNode(v.Elem(), node); ok {
 			if err != nil {
-				return fmt.Errorf("Unmarshal from cache error: %s, data: %s ", err.Error(), ret)
+				return fmt.Errorf("key: %s %s", key, err.Error())
 			}
 			return nil
 		}
 	}
 
-	tempKey := "{{ load_value ." + key + "}}"
-	tpl, ok := template.New("").Option("missingkey=error").Funcs(template.FuncMap{
-		"load_value": ctx.loader.Serialize,
-	}).Parse(tempKey)
-	if ok != nil {
-		return fmt.Errorf("key: %s not found(parse error)", key)
-	}
-	b := bytes.NewBuffer(nil)
-	err := tpl.Execute(b, ctx.Value)
-	if err != nil {
-		return fmt.Errorf("load from template failed: err: %s data: %s", err.Error(), b.String())
+	var data string
+	if v, ok := s.valueCache.Load(key); ok {
+		data = v.(string)
+	} else {
+		data, err = loader.Serialize(node)
+		if err != nil {
+			return fmt.Errorf("key: %s serialize error: %s", key, err.Error())
+		}
+		if !dynamic {
+			s.valueCache.Store(key, data)
+		}
 	}
-
-	data := b.String()
-	ctx.cache[key] = data
-	err = ctx.loader.Deserialize(data, result)
+	err = loader.Deserialize(data, result)
 	if err != nil {
-		return fmt.Errorf("Unmarshal error: %s, data: %s ", err.Error(), b.String())
+		return fmt.Errorf("Unmarshal error: %s, data: %s ", err.Error(), data)
 	}
 	return nil
 }
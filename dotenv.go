@@ -0,0 +1,287 @@
+package yfig
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// dotenv格式的ValueReader，每个变量作为一个顶层属性，值的类型转换与PropertiesReader一致
+type DotenvReader struct{}
+
+func NewDotenvReader() *DotenvReader {
+	return &DotenvReader{}
+}
+
+func (v *DotenvReader) Read(r io.Reader) (*Value, error) {
+	env, err := ParseDotenv(r)
+	if err != nil {
+		return nil, err
+	}
+	ret := Value{}
+	for k, v := range env {
+		ret[k] = parseScalar(v)
+	}
+	return &ret, nil
+}
+
+// 从dotenv文件读取变量，作为ExecTemplate中env函数及{{.Env.X}}的补充，同名的进程环境变量优先
+// 文件不存在时忽略
+func WithDotenv(filenames ...string) Opt {
+	return func(ctx *DefaultProperties) error {
+		ctx.dotenvFiles = append(ctx.dotenvFiles, filenames...)
+		return nil
+	}
+}
+
+// param: filename dotenv文件
+// return: 文件中定义的变量
+func ReadDotenvFile(filename string) (map[string]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseDotenv(f)
+}
+
+// 解析dotenv格式：
+// KEY=value，可带export前缀，#开头为注释
+// 未加引号的值去除首尾空白及" #"之后的行内注释
+// 单引号内容原样保留；双引号支持\n、\t、\"等转义，两者均可跨行
+// 未加引号及双引号的值支持${VAR}、${VAR:-default}、$VAR引用，与WithDotenv一致，优先使用进程环境变量，其次为文件中已定义的变量
+func ParseDotenv(r io.Reader) (map[string]string, error) {
+	buf := bytes.NewBuffer(nil)
+	if _, err := io.Copy(buf, r); err != nil {
+		return nil, err
+	}
+	p := &dotenvParser{
+		src:  strings.ReplaceAll(buf.String(), "\r\n", "\n"),
+		line: 1,
+		vars: map[string]string{},
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.vars, nil
+}
+
+type dotenvParser struct {
+	src  string
+	pos  int
+	line int
+	vars map[string]string
+}
+
+func (p *dotenvParser) errorf(format string, o ...interface{}) error {
+	return fmt.Errorf("dotenv: line %d: %s", p.line, fmt.Sprintf(format, o...))
+}
+
+func (p *dotenvParser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// 跳过行尾空白及注释
+func (p *dotenvParser) endLine() error {
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == '#' {
+		for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+			p.pos++
+		}
+	}
+	if p.pos < len(p.src) {
+		if p.src[p.pos] != '\n' {
+			return p.errorf("unexpected %q", p.src[p.pos])
+		}
+		p.pos++
+		p.line++
+	}
+	return nil
+}
+
+func (p *dotenvParser) parse() error {
+	for p.pos < len(p.src) {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return nil
+		}
+		if c := p.src[p.pos]; c == '\n' || c == '#' {
+			if err := p.endLine(); err != nil {
+				return err
+			}
+			continue
+		}
+		if strings.HasPrefix(p.src[p.pos:], "export ") || strings.HasPrefix(p.src[p.pos:], "export\t") {
+			p.pos += len("export")
+			p.skipSpace()
+		}
+		start := p.pos
+		for p.pos < len(p.src) && isEnvNameChar(p.src[p.pos]) {
+			p.pos++
+		}
+		key := p.src[start:p.pos]
+		if key == "" {
+			return p.errorf("invalid variable name")
+		}
+		p.skipSpace()
+		if p.pos >= len(p.src) || p.src[p.pos] != '=' {
+			return p.errorf("expect '=' after %s", key)
+		}
+		p.pos++
+		p.skipSpace()
+		value, err := p.parseValue()
+		if err != nil {
+			return err
+		}
+		p.vars[key] = value
+		if err := p.endLine(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isEnvNameChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' || c == '.' || c == '-'
+}
+
+func (p *dotenvParser) parseValue() (string, error) {
+	if p.pos >= len(p.src) {
+		return "", nil
+	}
+	switch p.src[p.pos] {
+	case '\'':
+		p.pos++
+		end := strings.IndexByte(p.src[p.pos:], '\'')
+		if end == -1 {
+			return "", p.errorf("unterminated single-quoted value")
+		}
+		value := p.src[p.pos : p.pos+end]
+		p.line += strings.Count(value, "\n")
+		p.pos += end + 1
+		return value, nil
+	case '"':
+		p.pos++
+		buf := strings.Builder{}
+		for {
+			if p.pos >= len(p.src) {
+				return "", p.errorf("unterminated double-quoted value")
+			}
+			c := p.src[p.pos]
+			p.pos++
+			switch c {
+			case '"':
+				return buf.String(), nil
+			case '\\':
+				if p.pos >= len(p.src) {
+					return "", p.errorf("unterminated double-quoted value")
+				}
+				e := p.src[p.pos]
+				p.pos++
+				switch e {
+				case 'n':
+					buf.WriteByte('\n')
+				case 'r':
+					buf.WriteByte('\r')
+				case 't':
+					buf.WriteByte('\t')
+				default:
+					buf.WriteByte(e)
+				}
+			case '$':
+				p.pos--
+				buf.WriteString(p.expand())
+			case '\n':
+				p.line++
+				buf.WriteByte(c)
+			default:
+				buf.WriteByte(c)
+			}
+		}
+	}
+	end := strings.IndexByte(p.src[p.pos:], '\n')
+	if end == -1 {
+		end = len(p.src) - p.pos
+	}
+	raw := p.src[p.pos : p.pos+end]
+	for i := 1; i < len(raw); i++ {
+		if raw[i] == '#' && (raw[i-1] == ' ' || raw[i-1] == '\t') {
+			raw = raw[:i]
+			break
+		}
+	}
+	raw = strings.TrimSpace(raw)
+	p.pos += end
+	sub := &dotenvParser{src: raw, vars: p.vars}
+	buf := strings.Builder{}
+	for sub.pos < len(sub.src) {
+		if sub.src[sub.pos] == '$' {
+			buf.WriteString(sub.expand())
+			continue
+		}
+		buf.WriteByte(sub.src[sub.pos])
+		sub.pos++
+	}
+	return buf.String(), nil
+}
+
+// 从当前位置的'$'开始解析变量引用并返回替换后的内容
+func (p *dotenvParser) expand() string {
+	p.pos++
+	if p.pos < len(p.src) && p.src[p.pos] == '{' {
+		end := strings.IndexByte(p.src[p.pos:], '}')
+		if end == -1 {
+			return "$"
+		}
+		expr := p.src[p.pos+1 : p.pos+end]
+		p.pos += end + 1
+		name, def := expr, ""
+		if i := strings.Index(expr, ":-"); i != -1 {
+			name, def = expr[:i], expr[i+2:]
+		}
+		if v, ok := p.lookup(name); ok && v != "" {
+			return v
+		}
+		return def
+	}
+	start := p.pos
+	for p.pos < len(p.src) && isEnvNameChar(p.src[p.pos]) && p.src[p.pos] != '.' && p.src[p.pos] != '-' {
+		p.pos++
+	}
+	if start == p.pos {
+		return "$"
+	}
+	v, _ := p.lookup(p.src[start:p.pos])
+	return v
+}
+
+func (p *dotenvParser) lookup(name string) (string, bool) {
+	if v, ok := os.LookupEnv(name); ok {
+		return v, true
+	}
+	v, ok := p.vars[name]
+	return v, ok
+}
+
+// 读取dotenv文件中的变量，补充到env中不存在的变量，文件不存在时忽略
+func mergeDotenv(env map[string]string, filenames []string) error {
+	for _, filename := range filenames {
+		vars, err := ReadDotenvFile(filename)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		for k, v := range vars {
+			if _, ok := env[k]; !ok {
+				env[k] = v
+			}
+		}
+	}
+	return nil
+}
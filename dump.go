@@ -0,0 +1,154 @@
+package yfig
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Dump使用的格式名称，每行输出一个"key=value"
+const DumpFlat = "flat"
+
+// Dump默认隐藏的属性名模式
+var DefaultRedactPatterns = []string{"*password*", "*secret*", "*token*"}
+
+type DumpOpt func(o *dumpOptions)
+
+type dumpOptions struct {
+	patterns []string
+	keys     []string
+}
+
+// 替换默认的属性名模式，属性名（完整路径）匹配任一模式时使用Redacted代替，不区分大小写，"*"匹配任意字符
+func RedactPatterns(patterns ...string) DumpOpt {
+	return func(o *dumpOptions) {
+		o.patterns = patterns
+	}
+}
+
+// 隐藏struct（或struct指针）中标记了secret的字段对应的属性，属性名根据fig、figPx tag计算，
+// 元素为struct的slice、map中的字段同样适用
+func RedactFields(structs ...interface{}) DumpOpt {
+	return func(o *dumpOptions) {
+		f := newFiller(false, []string{TagPrefixName}, []string{TagName}, false)
+		for _, s := range structs {
+			o.keys = append(o.keys, f.secretKeys(reflect.TypeOf(s), "", map[reflect.Type]bool{})...)
+		}
+	}
+}
+
+// 序列化当前属性值，敏感属性及解密过的加密属性的值使用Redacted代替
+// param: format 格式名称：DumpFlat；已注册的格式名称（如"yaml"、"json"），使用格式的ValueLoader；为空时使用prop的ValueLoader
+// param: opts 默认隐藏属性名匹配DefaultRedactPatterns的属性
+// return: 序列化的内容，格式不存在时返回错误
+func (ctx *DefaultProperties) Dump(format string, opts ...DumpOpt) (string, error) {
+	return Dump(ctx, format, opts...)
+}
+
+// 同DefaultProperties.Dump，可用于Sub、Snapshot等任意Properties
+func Dump(prop Properties, format string, opts ...DumpOpt) (string, error) {
+	o := &dumpOptions{patterns: DefaultRedactPatterns}
+	for _, opt := range opts {
+		opt(o)
+	}
+	// 属性值与加密的属性使用同一版本
+	s := prop.Snapshot()
+	o.keys = append(o.keys, s.state.encryptedKeys(s.prefix)...)
+	v := o.redact(s.AllSettings(), "")
+	if format == DumpFlat {
+		return dumpFlat(v), nil
+	}
+
+	loader := loaderOf(s)
+	if format != "" {
+		f, ok := LookupFormat(format)
+		if !ok {
+			return "", fmt.Errorf("unknown format %s", format)
+		}
+		loader = f.NewLoader()
+	}
+	return loader.Serialize(v)
+}
+
+func (o *dumpOptions) redact(node interface{}, key string) interface{} {
+	if key != "" && o.isSecret(key) {
+		return Redacted
+	}
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = o.redact(v[k], appendSegment(key, pathSegment{key: k}))
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = o.redact(v[i], appendSegment(key, pathSegment{index: i, isIndex: true}))
+		}
+	}
+	return node
+}
+
+func (o *dumpOptions) isSecret(key string) bool {
+	for _, p := range o.patterns {
+		if matchPattern(p, key) {
+			return true
+		}
+	}
+	for _, k := range o.keys {
+		if matchPattern(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func dumpFlat(v interface{}) string {
+	buf := strings.Builder{}
+	walkNode(v, "", func(key string, value interface{}) error {
+		if key == "" {
+			// 属性为空
+			return nil
+		}
+		var s string
+		switch o := value.(type) {
+		case nil:
+		case map[string]interface{}:
+			s = "{}"
+		case []interface{}:
+			s = "[]"
+		default:
+			s = strings.ReplaceAll(fmt.Sprint(o), "\n", `\n`)
+		}
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		buf.WriteString(s)
+		buf.WriteByte('\n')
+		return nil
+	})
+	return buf.String()
+}
+
+// 不区分大小写，"*"匹配任意字符（包括"."）
+func matchPattern(pattern, s string) bool {
+	pattern, s = strings.ToLower(pattern), strings.ToLower(s)
+	p, i := 0, 0
+	star, mark := -1, 0
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p, i
+			p++
+		case p < len(pattern) && pattern[p] == s[i]:
+			p++
+			i++
+		case star != -1:
+			mark++
+			p, i = star+1, mark
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
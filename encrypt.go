@@ -0,0 +1,414 @@
+package yfig
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	// 密钥环内容所在的环境变量
+	KeyringEnv = "YFIG_KEYRING"
+	// 密钥环文件路径所在的环境变量，KeyringEnv为空时使用
+	KeyringFileEnv = "YFIG_KEYRING_FILE"
+)
+
+var (
+	ErrNoKey = errors.New("no key to decrypt value")
+
+	encryptedPattern = regexp.MustCompile(`ENC\[AES256_GCM,[^\]]*\]`)
+)
+
+type keyringKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// AES-256-GCM密钥环，第一个密钥用于加密，全部密钥用于解密，轮换时将新密钥放在第一个即可
+type Keyring struct {
+	keys []keyringKey
+}
+
+// 解析密钥环，每个密钥以换行或","分隔，格式为"id:base64"或"base64"（id为密钥sha256的前8位十六进制），
+// 密钥为32字节，"#"开头的行为注释
+func ParseKeyring(s string) (*Keyring, error) {
+	ret := &Keyring{}
+	for _, line := range strings.FieldsFunc(s, func(r rune) bool { return r == '\n' || r == ',' }) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, data := "", line
+		if i := strings.Index(line, ":"); i != -1 {
+			id, data = strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		}
+		key, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %s: %s", id, err.Error())
+		}
+		if err := ret.Add(id, key); err != nil {
+			return nil, err
+		}
+	}
+	if len(ret.keys) == 0 {
+		return nil, errors.New("keyring is empty")
+	}
+	return ret, nil
+}
+
+// 依次从环境变量KeyringEnv、KeyringFileEnv指定的文件读取密钥环
+// return: 都未设置时返回nil
+func LoadKeyring() (*Keyring, error) {
+	if s := os.Getenv(KeyringEnv); s != "" {
+		return ParseKeyring(s)
+	}
+	if f := os.Getenv(KeyringFileEnv); f != "" {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		return ParseKeyring(string(b))
+	}
+	return nil, nil
+}
+
+// return: base64编码的随机32字节密钥
+func GenerateKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// 添加密钥，第一个添加的密钥用于加密
+// param: id 密钥id，为空时使用密钥sha256的前8位十六进制
+// param: key 32字节密钥
+func (k *Keyring) Add(id string, key []byte) error {
+	if len(key) != 32 {
+		return fmt.Errorf("key %s must be 32 bytes", id)
+	}
+	if id == "" {
+		sum := sha256.Sum256(key)
+		id = hex.EncodeToString(sum[:4])
+	}
+	if strings.ContainsAny(id, ",:]") {
+		return fmt.Errorf("invalid key id %s", id)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	k.keys = append(k.keys, keyringKey{id: id, aead: aead})
+	return nil
+}
+
+// return: 加密使用的密钥id
+func (k *Keyring) Primary() string {
+	if len(k.keys) == 0 {
+		return ""
+	}
+	return k.keys[0].id
+}
+
+// return: ENC[AES256_GCM,data:...,iv:...,tag:...,kid:...]
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if len(k.keys) == 0 {
+		return "", errors.New("keyring is empty")
+	}
+	key := k.keys[0]
+	nonce := make([]byte, key.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := key.aead.Seal(nil, nonce, []byte(plaintext), nil)
+	n := len(sealed) - key.aead.Overhead()
+	enc := base64.StdEncoding.EncodeToString
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,kid:%s]",
+		enc(sealed[:n]), enc(nonce), enc(sealed[n:]), key.id), nil
+}
+
+// param: value Encrypt的结果，没有kid时依次尝试全部密钥
+func (k *Keyring) Decrypt(value string) (string, error) {
+	fields, err := parseEncrypted(value)
+	if err != nil {
+		return "", err
+	}
+	var parts [3][]byte
+	for i, name := range []string{"data", "iv", "tag"} {
+		if parts[i], err = base64.StdEncoding.DecodeString(fields[name]); err != nil {
+			return "", fmt.Errorf("invalid encrypted value: %s %s", name, err.Error())
+		}
+	}
+	kid, hasKid := fields["kid"]
+	for _, key := range k.keys {
+		if hasKid && key.id != kid || len(parts[1]) != key.aead.NonceSize() {
+			continue
+		}
+		ret, err := key.aead.Open(nil, parts[1], append(parts[0], parts[2]...), nil)
+		if err == nil {
+			return string(ret), nil
+		}
+		if hasKid {
+			return "", fmt.Errorf("decrypt with key %s failed: %s", kid, err.Error())
+		}
+	}
+	if hasKid {
+		return "", fmt.Errorf("%w: key %s not found", ErrNoKey, kid)
+	}
+	return "", ErrNoKey
+}
+
+// 使用第一个密钥重新加密s中其他密钥加密的全部ENC[...]，其余内容不变
+func (k *Keyring) Rotate(s string) (string, error) {
+	var err error
+	ret := encryptedPattern.ReplaceAllStringFunc(s, func(value string) string {
+		if err != nil {
+			return value
+		}
+		if fields, e := parseEncrypted(value); e == nil && fields["kid"] == k.Primary() {
+			return value
+		}
+		var plain string
+		if plain, err = k.Decrypt(value); err != nil {
+			return value
+		}
+		var enc string
+		if enc, err = k.Encrypt(plain); err != nil {
+			return value
+		}
+		return enc
+	})
+	return ret, err
+}
+
+// return: s是否为ENC[AES256_GCM,...]格式的加密值
+func IsEncrypted(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, "ENC[AES256_GCM,") && strings.HasSuffix(s, "]")
+}
+
+func parseEncrypted(value string) (map[string]string, error) {
+	value = strings.TrimSpace(value)
+	if !IsEncrypted(value) {
+		return nil, errors.New("invalid encrypted value")
+	}
+	ret := map[string]string{}
+	for _, f := range strings.Split(value[len("ENC[AES256_GCM,"):len(value)-1], ",") {
+		i := strings.Index(f, ":")
+		if i == -1 {
+			return nil, fmt.Errorf("invalid encrypted value: %s", f)
+		}
+		ret[f[:i]] = f[i+1:]
+	}
+	for _, name := range []string{"data", "iv", "tag"} {
+		if _, ok := ret[name]; !ok {
+			return nil, fmt.Errorf("invalid encrypted value: missing %s", name)
+		}
+	}
+	return ret, nil
+}
+
+// 使用指定的密钥环解密属性值，未指定时在遇到加密值时使用LoadKeyring
+func WithKeyring(k *Keyring) Opt {
+	return func(ctx *DefaultProperties) error {
+		ctx.keyring.set(k)
+		return nil
+	}
+}
+
+// 未指定密钥环时，在第一次需要解密时使用LoadKeyring加载
+type lazyKeyring struct {
+	keyring *Keyring
+	lock    sync.Mutex
+}
+
+func (l *lazyKeyring) set(k *Keyring) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.keyring = k
+}
+
+func (l *lazyKeyring) get() (*Keyring, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.keyring != nil {
+		return l.keyring, nil
+	}
+	k, err := LoadKeyring()
+	if err != nil {
+		return nil, err
+	}
+	if k == nil {
+		return nil, fmt.Errorf("%w: set %s or %s", ErrNoKey, KeyringEnv, KeyringFileEnv)
+	}
+	l.keyring = k
+	return k, nil
+}
+
+// 加密的属性值，WriteTo时未修改的值写回密文，Dump时总是隐藏
+type encryptedValue struct {
+	cipher string
+	plain  string
+}
+
+// 解密v中全部ENC[...]格式的字符串，v本身不修改
+// return: 解密后的属性值，加密的属性（属性名 -> 密文及明文），没有加密的属性时为nil
+func (ctx *DefaultProperties) decryptValue(v Value) (Value, map[string]encryptedValue, error) {
+	found := map[string]encryptedValue{}
+	var decrypt func(node interface{}, key string) (interface{}, bool, error)
+	decrypt = func(node interface{}, key string) (interface{}, bool, error) {
+		switch o := node.(type) {
+		case string:
+			if !IsEncrypted(o) {
+				return node, false, nil
+			}
+			k, err := ctx.keyring.get()
+			if err != nil {
+				return nil, false, fmt.Errorf("key: %s %w", key, err)
+			}
+			plain, err := k.Decrypt(o)
+			if err != nil {
+				return nil, false, fmt.Errorf("key: %s %w", key, err)
+			}
+			found[key] = encryptedValue{cipher: o, plain: plain}
+			return plain, true, nil
+		case map[string]interface{}:
+			var ret map[string]interface{}
+			for k, child := range o {
+				c, changed, err := decrypt(child, appendSegment(key, pathSegment{key: k}))
+				if err != nil {
+					return nil, false, err
+				}
+				if !changed {
+					continue
+				}
+				if ret == nil {
+					ret = make(map[string]interface{}, len(o))
+					for k, child := range o {
+						ret[k] = child
+					}
+				}
+				ret[k] = c
+			}
+			if ret != nil {
+				return ret, true, nil
+			}
+		case []interface{}:
+			var ret []interface{}
+			for i, child := range o {
+				c, changed, err := decrypt(child, appendSegment(key, pathSegment{index: i, isIndex: true}))
+				if err != nil {
+					return nil, false, err
+				}
+				if !changed {
+					continue
+				}
+				if ret == nil {
+					ret = append([]interface{}(nil), o...)
+				}
+				ret[i] = c
+			}
+			if ret != nil {
+				return ret, true, nil
+			}
+		}
+		return node, false, nil
+	}
+	ret, changed, err := decrypt(v, "")
+	if err != nil || !changed {
+		return v, nil, err
+	}
+	return ret.(map[string]interface{}), found, nil
+}
+
+// 修改或删除path对应的属性后更新加密的属性：删除path本身、父属性及子属性的记录，
+// 删除列表元素时之后元素的记录下标前移
+// return: 更新后的副本，m本身不修改
+func updateEncrypted(m map[string]encryptedValue, path []pathSegment, deleted bool) map[string]encryptedValue {
+	if len(m) == 0 {
+		return m
+	}
+	last := len(path) - 1
+	ret := make(map[string]encryptedValue, len(m))
+	for k, e := range m {
+		p, err := parsePath(k)
+		if err != nil {
+			continue
+		}
+		n := len(path)
+		if len(p) < n {
+			n = len(p)
+		}
+		if segmentsEqual(p[:n], path[:n]) {
+			continue
+		}
+		if deleted && path[last].isIndex && len(p) > last && p[last].isIndex && p[last].index > path[last].index &&
+			segmentsEqual(p[:last], path[:last]) {
+			p = append([]pathSegment(nil), p...)
+			p[last].index--
+			k = joinSegments(p)
+		}
+		ret[k] = e
+	}
+	return ret
+}
+
+func segmentsEqual(a, b []pathSegment) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// return: 值与解密结果相同的属性替换回密文后的副本
+func encryptBack(v Value, encrypted map[string]encryptedValue) Value {
+	if len(encrypted) == 0 {
+		return v
+	}
+	ret := copyValue(v).(map[string]interface{})
+	for key, e := range encrypted {
+		if node, err := lookupPath(&ret, key); err == nil && node == e.plain {
+			path, _ := parsePath(key)
+			setNode(ret, path, e.cipher)
+		}
+	}
+	return ret
+}
+
+// param: prefix 属性名前缀，为空时返回全部
+// return: 前缀下加密的属性名（去掉前缀）
+func (s *propState) encryptedKeys(prefix string) []string {
+	if path, err := parsePath(prefix); err == nil {
+		prefix = joinSegments(path)
+	}
+	var ret []string
+	for key := range s.encrypted {
+		if prefix == "" {
+			ret = append(ret, key)
+		} else if strings.HasPrefix(key, prefix+".") {
+			ret = append(ret, key[len(prefix)+1:])
+		}
+	}
+	return ret
+}
@@ -0,0 +1,176 @@
+package yfig
+
+import (
+	"sort"
+	"strings"
+)
+
+// 需要参考低优先级合并结果的数据源，LayeredProperties会调用Overlay代替Load
+type OverlaySource interface {
+	Source
+	// param: ctx 属性
+	// param: base 低优先级数据源的合并结果，不允许修改
+	// return: 需要覆盖的属性值
+	Overlay(ctx *DefaultProperties, base Value) (*Value, error)
+}
+
+// 使用环境变量覆盖属性，如Prefix为"APP"时APP_DATASOURCES_DEFAULT_DRIVERNAME覆盖DataSources.default.DriverName
+// 环境变量名去掉前缀后按Separator分割，依次与已有属性名匹配，允许属性名本身包含Separator（优先匹配最长的属性名）
+// 环境变量的值为true/false或十进制数字时转换为对应类型，否则为字符串
+type EnvSource struct {
+	// 环境变量前缀，为空时匹配全部环境变量
+	Prefix string
+	// 环境变量名分隔符，默认"_"
+	Separator string
+	// 为true时环境变量名与属性名大小写必须一致
+	CaseSensitive bool
+	// 为true时允许创建已有属性中不存在的属性，新属性名使用环境变量名（CaseSensitive为false时转为小写）
+	AllowNew bool
+}
+
+func NewEnvSource(prefix string) *EnvSource {
+	return &EnvSource{
+		Prefix:    prefix,
+		Separator: "_",
+	}
+}
+
+func (s *EnvSource) Name() string {
+	if s.Prefix == "" {
+		return "env"
+	}
+	return "env:" + s.Prefix
+}
+
+func (s *EnvSource) Load(ctx *DefaultProperties) (*Value, error) {
+	return s.Overlay(ctx, Value{})
+}
+
+func (s *EnvSource) Overlay(ctx *DefaultProperties, base Value) (*Value, error) {
+	sep := s.Separator
+	if sep == "" {
+		sep = "_"
+	}
+	prefix := s.Prefix
+	if prefix != "" {
+		prefix += sep
+	}
+
+	env := ctx.Env
+	if env == nil {
+		env = GetEnvs()
+	}
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ret := Value{}
+	for _, name := range names {
+		if !s.hasPrefix(name, prefix) || len(name) == len(prefix) {
+			continue
+		}
+		segs := strings.Split(name[len(prefix):], sep)
+		path, ok := s.resolve(base, segs, sep)
+		if !ok {
+			if !s.AllowNew {
+				continue
+			}
+			path = s.newPath(base, segs, sep)
+		}
+		setPath(ret, path, parseScalar(env[name]))
+	}
+	return &ret, nil
+}
+
+func (s *EnvSource) hasPrefix(name, prefix string) bool {
+	if len(name) < len(prefix) {
+		return false
+	}
+	if s.CaseSensitive {
+		return name[:len(prefix)] == prefix
+	}
+	return strings.EqualFold(name[:len(prefix)], prefix)
+}
+
+// 在node中查找与segs匹配的属性路径
+func (s *EnvSource) resolve(node map[string]interface{}, segs []string, sep string) ([]string, bool) {
+	keys := sortedKeys(node)
+	for i := len(segs); i > 0; i-- {
+		candidate := strings.Join(segs[:i], sep)
+		for _, k := range keys {
+			if !s.match(k, candidate) {
+				continue
+			}
+			if i == len(segs) {
+				return []string{k}, true
+			}
+			if child, ok := node[k].(map[string]interface{}); ok {
+				if path, ok := s.resolve(child, segs[i:], sep); ok {
+					return append([]string{k}, path...), true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+// 尽可能匹配已有的map属性，剩余部分作为新属性名
+func (s *EnvSource) newPath(node map[string]interface{}, segs []string, sep string) []string {
+	var path []string
+	for len(segs) > 0 {
+		found := false
+		for _, k := range sortedKeys(node) {
+			if !s.match(k, segs[0]) {
+				continue
+			}
+			if child, ok := node[k].(map[string]interface{}); ok {
+				path = append(path, k)
+				node = child
+				segs = segs[1:]
+				found = true
+				break
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	for _, seg := range segs {
+		if !s.CaseSensitive {
+			seg = strings.ToLower(seg)
+		}
+		path = append(path, seg)
+	}
+	return path
+}
+
+func (s *EnvSource) match(key, candidate string) bool {
+	if s.CaseSensitive {
+		return key == candidate
+	}
+	return strings.EqualFold(key, candidate)
+}
+
+func sortedKeys(node map[string]interface{}) []string {
+	keys := make([]string, 0, len(node))
+	for k := range node {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// 按路径设置属性值，路径中间节点不是map时替换为map
+func setPath(node map[string]interface{}, path []string, value interface{}) {
+	for _, k := range path[:len(path)-1] {
+		child, ok := node[k].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			node[k] = child
+		}
+		node = child
+	}
+	node[path[len(path)-1]] = value
+}
@@ -0,0 +1,593 @@
+package yfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Fill系列方法的实现，递归填充嵌套的struct、struct指针以及元素为struct的slice、array和map
+// 只有包含fig/figPx tag的struct类型才会递归填充，其他类型通过GetValue整体反序列化
+type filler struct {
+	withField  bool
+	tagPxNames []string
+	tagNames   []string
+	// 为true时使用FillExWithTagNames的行为：GetValue失败作为错误返回
+	multi bool
+	// 属性不存在、类型不匹配时返回*KeyError
+	strict bool
+	// 检查figPx前缀下没有被字段使用的属性
+	unknown bool
+
+	errs Errors
+	// 已填充的属性，key为属性所在的Properties
+	used     map[Properties]map[string]bool
+	prefixes []prefixKey
+}
+
+type prefixKey struct {
+	prop    Properties
+	key     string
+	display string
+}
+
+var (
+	ErrKeyNotFound  = errors.New("key not found")
+	ErrTypeMismatch = errors.New("type mismatch")
+	ErrUnknownKey   = errors.New("unknown key")
+)
+
+// 严格模式下属性不存在、类型不匹配或属性未被使用的信息
+type KeyError struct {
+	// 字段名，属性未被使用时为空
+	Field string
+	// 属性名
+	Key string
+	// 包装ErrKeyNotFound、ErrTypeMismatch或ErrUnknownKey，可使用errors.Is判断
+	Err error
+}
+
+func (e *KeyError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("field %s (key: %s): %s", e.Field, e.Key, e.Err.Error())
+	}
+	return fmt.Sprintf("key %s: %s", e.Key, e.Err.Error())
+}
+
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+func newFiller(withField bool, tagPxNames, tagNames []string, multi bool) *filler {
+	return &filler{
+		withField:  withField,
+		tagPxNames: tagPxNames,
+		tagNames:   tagNames,
+		multi:      multi,
+	}
+}
+
+func structPtrValue(result interface{}) (reflect.Value, error) {
+	t := reflect.TypeOf(result)
+	v := reflect.ValueOf(result)
+
+	if t == nil || t.Kind() != reflect.Ptr {
+		return reflect.Value{}, errors.New("result must be ptr")
+	}
+	if t.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, errors.New("result must be struct ptr")
+	}
+	return v.Elem(), nil
+}
+
+func (f *filler) fill(prop Properties, result interface{}) error {
+	v, err := structPtrValue(result)
+	if err != nil {
+		return err
+	}
+	f.fillStruct(prop, v, "", "", "")
+	if f.unknown {
+		f.checkUnknown()
+	}
+	f.errs = append(f.errs, f.validateStruct(v, "", "")...)
+	if f.errs.Empty() {
+		return nil
+	}
+	return f.errs
+}
+
+// param: kind 严格模式下的错误类型
+func (f *filler) fail(display, fieldPath string, kind, err error) {
+	logf(err.Error())
+	if !f.strict {
+		if f.multi {
+			f.errs.AddError(err)
+		}
+		return
+	}
+	if kind != ErrKeyNotFound {
+		kind = fmt.Errorf("%w: %s", kind, err.Error())
+	}
+	f.errs.AddError(&KeyError{Field: fieldPath, Key: display, Err: kind})
+}
+
+func (f *filler) markUsed(prop Properties, key string) {
+	if !f.unknown {
+		return
+	}
+	if f.used == nil {
+		f.used = map[Properties]map[string]bool{}
+	}
+	if f.used[prop] == nil {
+		f.used[prop] = map[string]bool{}
+	}
+	f.used[prop][key] = true
+}
+
+// 严格模式下，struct指针属性不存在时保持nil，不视为错误；required选项在任何模式下都返回错误
+func (f *filler) missing(display, fieldPath string, ft reflect.Type, err error, opts tagOptions) {
+	if opts.required {
+		logf(err.Error())
+		f.errs.AddError(&KeyError{Field: fieldPath, Key: display, Err: ErrKeyNotFound})
+		return
+	}
+	if f.strict && ft.Kind() == reflect.Ptr {
+		logf(err.Error())
+		return
+	}
+	f.fail(display, fieldPath, ErrKeyNotFound, err)
+}
+
+// 计算字段对应的属性名（不含上层前缀），遇到figPx tag时更新prefix
+// return: 属性名，tag选项，字段不需要填充时返回false
+func (f *filler) fieldKey(field reflect.StructField, prefix []string) (string, tagOptions, bool) {
+	for tagIndex := range f.tagPxNames {
+		tagValue := field.Tag.Get(f.tagPxNames[tagIndex])
+		if tagValue != "" {
+			prefix[tagIndex] = tagValue
+			continue
+		}
+		tagValue = field.Tag.Get(f.tagNames[tagIndex])
+		if tagValue != "" {
+			if tagValue == "-" {
+				return "", tagOptions{}, false
+			}
+		} else if tagIndex < len(f.tagPxNames)-1 {
+			continue
+		} else if f.withField {
+			tagValue = field.Name
+		}
+
+		if tagValue == "" {
+			return "", tagOptions{}, false
+		}
+		opts := parseTag(tagValue)
+		return joinKey(prefix[tagIndex], opts.name), opts, true
+	}
+	return "", tagOptions{}, false
+}
+
+// 匿名嵌入且没有tag的struct，其字段视为外层struct的字段
+func (f *filler) isEmbedded(field reflect.StructField) bool {
+	if !field.Anonymous {
+		return false
+	}
+	for i := range f.tagNames {
+		if field.Tag.Get(f.tagNames[i]) != "" || field.Tag.Get(f.tagPxNames[i]) != "" {
+			return false
+		}
+	}
+	return f.isFillStruct(field.Type)
+}
+
+// 是否为需要递归填充的struct或struct指针
+func (f *filler) isFillStruct(t reflect.Type) bool {
+	names := append(append([]string(nil), f.tagNames...), f.tagPxNames...)
+	return structHasTag(t, names, map[reflect.Type]bool{})
+}
+
+func structHasTag(t reflect.Type, names []string, visited map[reflect.Type]bool) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || visited[t] {
+		return false
+	}
+	visited[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		for _, name := range names {
+			if field.Tag.Get(name) != "" {
+				return true
+			}
+		}
+		if field.Anonymous && structHasTag(field.Type, names, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// 元素为需要递归填充的struct的slice、array、map（key为string）
+func (f *filler) isFillContainer(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return f.isFillStruct(t.Elem())
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && f.isFillStruct(t.Elem())
+	}
+	return false
+}
+
+// param: prop 属性
+// param: v struct
+// param: base prop中的属性前缀
+// param: display 错误信息中使用的属性前缀
+// param: fieldPath 错误信息中使用的字段前缀
+func (f *filler) fillStruct(prop Properties, v reflect.Value, base, display, fieldPath string) {
+	t := v.Type()
+	prefix := make([]string, len(f.tagPxNames))
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldValue := v.Field(i)
+		if f.isEmbedded(field) {
+			if ev, ok := allocStruct(fieldValue); ok {
+				f.fillStruct(prop, ev, base, display, fieldPath)
+			}
+			continue
+		}
+		px := append([]string(nil), prefix...)
+		key, opts, ok := f.fieldKey(field, prefix)
+		if f.unknown {
+			f.addPrefixes(prop, px, prefix, base, display)
+		}
+		if !ok {
+			continue
+		}
+		f.fillField(prop, fieldValue, field.Type, joinKey(base, key), joinKey(display, key),
+			joinKey(fieldPath, field.Name), opts)
+	}
+}
+
+// struct或struct指针（为nil时分配）
+func allocStruct(v reflect.Value) (reflect.Value, bool) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			if !v.CanSet() {
+				return reflect.Value{}, false
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return allocStruct(v.Elem())
+	}
+	return v, v.Kind() == reflect.Struct
+}
+
+func (f *filler) fillField(prop Properties, fieldValue reflect.Value, ft reflect.Type, key, display, fieldPath string, opts tagOptions) {
+	if f.isFillStruct(ft) {
+		if !fieldValue.CanSet() {
+			return
+		}
+		if _, err := getRawValue(prop, key); err != nil {
+			f.missing(display, fieldPath, ft, err, opts)
+			return
+		}
+		if sv, ok := allocStruct(fieldValue); ok {
+			f.fillStruct(prop, sv, key, display, fieldPath)
+		}
+		return
+	}
+	if f.isFillContainer(ft) {
+		if !fieldValue.CanSet() {
+			return
+		}
+		raw, err := getRawValue(prop, key)
+		if err != nil {
+			f.missing(display, fieldPath, ft, err, opts)
+			return
+		}
+		f.markUsed(prop, key)
+		f.fillContainer(prop, fieldValue, raw, display, fieldPath)
+		return
+	}
+
+	f.markUsed(prop, key)
+	if !fieldValue.CanSet() {
+		return
+	}
+	raw, err := getRawValue(prop, key)
+	if err != nil {
+		if s, ok := fallbackValue(prop, opts); ok {
+			if err := setFromString(fieldValue, s, opts.sep, opts.layout, loaderOf(prop)); err != nil {
+				f.fail(display, fieldPath, ErrTypeMismatch, redactError(key, err, opts))
+			}
+			return
+		}
+		f.missing(display, fieldPath, ft, err, opts)
+		if !f.multi && !f.strict && !opts.required {
+			fieldValue.Set(reflect.Zero(ft))
+		}
+		return
+	}
+
+	if s, ok := raw.(string); ok && (opts.sep != "" || opts.layout != "") {
+		err = setFromString(fieldValue, s, opts.sep, opts.layout, loaderOf(prop))
+	} else {
+		c := reflect.New(ft)
+		err = prop.GetValue(key, c.Interface())
+		if err == nil {
+			fieldValue.Set(c.Elem())
+		}
+	}
+	if err != nil {
+		f.fail(display, fieldPath, ErrTypeMismatch, redactError(key, err, opts))
+		if !f.multi && !f.strict {
+			fieldValue.Set(reflect.Zero(ft))
+		}
+	}
+}
+
+// 属性不存在时依次使用env、default选项的值
+func fallbackValue(prop Properties, opts tagOptions) (string, bool) {
+	if opts.env != "" {
+		if v, ok := lookupEnv(prop, opts.env); ok {
+			return v, true
+		}
+	}
+	return opts.defaultValue, opts.hasDefault
+}
+
+func lookupEnv(prop Properties, name string) (string, bool) {
+	if p, ok := prop.(interface {
+		lookupEnv(name string) (string, bool)
+	}); ok {
+		return p.lookupEnv(name)
+	}
+	return os.LookupEnv(name)
+}
+
+// secret字段的错误信息中不包含属性值
+func redactError(key string, err error, opts tagOptions) error {
+	if !opts.secret {
+		return err
+	}
+	return fmt.Errorf("key: %s cannot convert value %s", key, Redacted)
+}
+
+func loaderOf(prop Properties) ValueLoader {
+	if l, ok := prop.(interface{ getLoader() ValueLoader }); ok {
+		return l.getLoader()
+	}
+	return NewYamlLoader()
+}
+
+func (f *filler) fillContainer(prop Properties, fieldValue reflect.Value, raw interface{}, display, fieldPath string) {
+	ft := fieldValue.Type()
+	if ft.Kind() == reflect.Map {
+		m, ok := raw.(map[string]interface{})
+		if !ok {
+			f.fail(display, fieldPath, ErrTypeMismatch, fmt.Errorf("key: %s expect map but get %T", display, raw))
+			return
+		}
+		ret := reflect.MakeMapWithSize(ft, len(m))
+		for _, k := range sortedKeys(m) {
+			ev := reflect.New(ft.Elem()).Elem()
+			if f.fillElem(prop, ev, m[k], joinKey(display, k), fmt.Sprintf("%s[%s]", fieldPath, k)) {
+				ret.SetMapIndex(reflect.ValueOf(k).Convert(ft.Key()), ev)
+			}
+		}
+		fieldValue.Set(ret)
+		return
+	}
+
+	list, ok := raw.([]interface{})
+	if !ok {
+		f.fail(display, fieldPath, ErrTypeMismatch, fmt.Errorf("key: %s expect list but get %T", display, raw))
+		return
+	}
+	ret := fieldValue
+	if ft.Kind() == reflect.Slice {
+		ret = reflect.MakeSlice(ft, len(list), len(list))
+	}
+	for i := 0; i < len(list) && i < ret.Len(); i++ {
+		f.fillElem(prop, ret.Index(i), list[i], fmt.Sprintf("%s[%d]", display, i), fmt.Sprintf("%s[%d]", fieldPath, i))
+	}
+	if ft.Kind() == reflect.Slice {
+		fieldValue.Set(ret)
+	}
+}
+
+func (f *filler) fillElem(prop Properties, ev reflect.Value, raw interface{}, display, fieldPath string) bool {
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		f.fail(display, fieldPath, ErrTypeMismatch, fmt.Errorf("key: %s expect map but get %T", display, raw))
+		return false
+	}
+	sv, ok := allocStruct(ev)
+	if !ok {
+		return false
+	}
+	f.fillStruct(elemProperties(prop, m), sv, "", display, fieldPath)
+	return true
+}
+
+// 记录字段处理后新出现的figPx前缀
+func (f *filler) addPrefixes(prop Properties, before, after []string, base, display string) {
+	for i := range after {
+		if after[i] != "" && after[i] != before[i] {
+			f.prefixes = append(f.prefixes, prefixKey{
+				prop:    prop,
+				key:     joinKey(base, after[i]),
+				display: joinKey(display, after[i]),
+			})
+		}
+	}
+}
+
+// 检查figPx前缀下没有被任何字段使用的属性
+func (f *filler) checkUnknown() {
+	reported := map[string]bool{}
+	for _, px := range f.prefixes {
+		raw, err := getRawValue(px.prop, px.key)
+		if err != nil {
+			continue
+		}
+		used := f.used[px.prop]
+		if used[px.key] {
+			continue
+		}
+		if m, ok := raw.(map[string]interface{}); ok {
+			for _, k := range sortedKeys(m) {
+				f.findUnknown(used, reported, m[k], joinKey(px.key, k), joinKey(px.display, k))
+			}
+		}
+	}
+}
+
+func (f *filler) findUnknown(used, reported map[string]bool, raw interface{}, key, display string) {
+	if used[key] || reported[display] {
+		return
+	}
+	m, ok := raw.(map[string]interface{})
+	if !ok || !hasUsedChild(used, key) {
+		reported[display] = true
+		f.errs.AddError(&KeyError{Key: display, Err: ErrUnknownKey})
+		return
+	}
+	for _, k := range sortedKeys(m) {
+		f.findUnknown(used, reported, m[k], joinKey(key, k), joinKey(display, k))
+	}
+}
+
+func hasUsedChild(used map[string]bool, key string) bool {
+	for k := range used {
+		if strings.HasPrefix(k, key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// 以v为根的属性，使用prop的ValueLoader
+func elemProperties(prop Properties, v Value) Properties {
+	ret := New(WithValue(v))
+	ret.SetValueLoader(loaderOf(prop))
+	return ret
+}
+
+// 收集标记了secret的字段对应的属性名，slice、array的元素使用"key[*]"，map的元素使用"key.*"
+func (f *filler) secretKeys(t reflect.Type, base string, visited map[reflect.Type]bool) []string {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || visited[t] {
+		return nil
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	var ret []string
+	prefix := make([]string, len(f.tagPxNames))
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if f.isEmbedded(field) {
+			ret = append(ret, f.secretKeys(field.Type, base, visited)...)
+			continue
+		}
+		key, opts, ok := f.fieldKey(field, prefix)
+		if !ok {
+			continue
+		}
+		key = joinKey(base, key)
+		switch {
+		case opts.secret:
+			ret = append(ret, key)
+		case f.isFillStruct(field.Type):
+			ret = append(ret, f.secretKeys(field.Type, key, visited)...)
+		case f.isFillContainer(field.Type):
+			elem := key + "[*]"
+			if field.Type.Kind() == reflect.Map {
+				elem = joinKey(key, "*")
+			}
+			ret = append(ret, f.secretKeys(field.Type.Elem(), elem, visited)...)
+		}
+	}
+	return ret
+}
+
+// 递归校验validate tag，返回的错误均为*FieldError
+func (f *filler) validateStruct(v reflect.Value, display, fieldPath string) Errors {
+	t := v.Type()
+	errs := Errors{}
+	keys := map[int]string{}
+	secrets := map[int]bool{}
+	prefix := make([]string, len(f.tagPxNames))
+	names := append(append([]string(nil), f.tagNames...), f.tagPxNames...)
+	names = append(names, ValidateTagName)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldValue := v.Field(i)
+		if f.isEmbedded(field) {
+			if ev, ok := derefStruct(fieldValue); ok {
+				errs = append(errs, f.validateStruct(ev, display, fieldPath)...)
+			}
+			continue
+		}
+		key, opts, ok := f.fieldKey(field, prefix)
+		if !ok {
+			key = field.Name
+		} else {
+			keys[i] = joinKey(display, key)
+			secrets[i] = opts.secret
+		}
+		if !structHasTag(field.Type, names, map[reflect.Type]bool{}) && !isStructContainer(field.Type, names) {
+			continue
+		}
+		subDisplay := joinKey(display, key)
+		subPath := joinKey(fieldPath, field.Name)
+		switch fieldValue.Kind() {
+		case reflect.Slice, reflect.Array:
+			for j := 0; j < fieldValue.Len(); j++ {
+				if ev, ok := derefStruct(fieldValue.Index(j)); ok {
+					errs = append(errs, f.validateStruct(ev, fmt.Sprintf("%s[%d]", subDisplay, j), fmt.Sprintf("%s[%d]", subPath, j))...)
+				}
+			}
+		case reflect.Map:
+			iter := fieldValue.MapRange()
+			for iter.Next() {
+				k := fmt.Sprintf("%v", iter.Key().Interface())
+				if ev, ok := derefStruct(iter.Value()); ok {
+					errs = append(errs, f.validateStruct(ev, joinKey(subDisplay, k), fmt.Sprintf("%s[%s]", subPath, k))...)
+				}
+			}
+		default:
+			if ev, ok := derefStruct(fieldValue); ok {
+				errs = append(errs, f.validateStruct(ev, subDisplay, subPath)...)
+			}
+		}
+	}
+	if err := validateFields(v, keys, secrets, fieldPath); err != nil {
+		errs = append(errs, err.(Errors)...)
+	}
+	return errs
+}
+
+func derefStruct(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	return v, v.Kind() == reflect.Struct
+}
+
+func isStructContainer(t reflect.Type, names []string) bool {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return structHasTag(t.Elem(), names, map[reflect.Type]bool{})
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package yfig
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	SetFlagName = "set"
+	UsageTag    = "usage"
+)
+
+// 可重复的key=value命令行参数，如 -set ServerPort=9090 -set DataSources.default.DriverName=mysql
+type SetFlag []string
+
+func (s *SetFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *SetFlag) Set(v string) error {
+	if strings.Index(v, "=") <= 0 {
+		return fmt.Errorf("expect key=value but get %q", v)
+	}
+	*s = append(*s, v)
+	return nil
+}
+
+// 使用命令行参数覆盖属性，参数名即属性名（如 -ServerPort=9090），只有显式设置的参数生效
+// -set key=value参数优先于同名的普通参数
+type FlagSource struct {
+	FlagSet *flag.FlagSet
+	Sets    SetFlag
+}
+
+// 在fs上注册-set参数，需要在fs.Parse之前调用
+func NewFlagSource(fs *flag.FlagSet) *FlagSource {
+	ret := &FlagSource{
+		FlagSet: fs,
+	}
+	fs.Var(&ret.Sets, SetFlagName, "override property, format: key=value (repeatable)")
+	return ret
+}
+
+func (s *FlagSource) Name() string {
+	return "flag"
+}
+
+func (s *FlagSource) Load(ctx *DefaultProperties) (*Value, error) {
+	return s.Overlay(ctx, Value{})
+}
+
+func (s *FlagSource) Overlay(ctx *DefaultProperties, base Value) (*Value, error) {
+	ret := Value{}
+	if s.FlagSet == nil || !s.FlagSet.Parsed() {
+		return &ret, nil
+	}
+	s.FlagSet.Visit(func(f *flag.Flag) {
+		if f.Value == &s.Sets {
+			return
+		}
+		setPath(ret, strings.Split(f.Name, "."), flagValue(f.Value))
+	})
+	for _, kv := range s.Sets {
+		i := strings.Index(kv, "=")
+		setPath(ret, strings.Split(kv[:i], "."), parseScalar(kv[i+1:]))
+	}
+	return &ret, nil
+}
+
+// 非string类型字段对应的参数，取值时按parseScalar转换
+type scalarFlag struct {
+	value string
+}
+
+func (f *scalarFlag) String() string {
+	return f.value
+}
+
+func (f *scalarFlag) Set(v string) error {
+	f.value = v
+	return nil
+}
+
+func (f *scalarFlag) Get() interface{} {
+	return parseScalar(f.value)
+}
+
+func flagValue(v flag.Value) interface{} {
+	if g, ok := v.(flag.Getter); ok {
+		switch o := g.Get().(type) {
+		case string:
+			return o
+		case bool:
+			return o
+		}
+	}
+	return parseScalar(v.String())
+}
+
+// 根据struct的fig/figPx tag在fs上注册命令行参数，参数名为完整的属性名，嵌套的struct与Fill相同方式处理
+// 参数默认值取自tag的default=选项，帮助信息取自usage tag
+// param: fs 命令行参数
+// param: result struct指针
+// result: result如果不为struct的指针返回错误
+func BindFlags(fs *flag.FlagSet, result interface{}) error {
+	return BindFlagsWithTagName(fs, result, TagPrefixName, TagName)
+}
+
+// param: fs 命令行参数
+// param: result struct指针
+// param: tagPxName tag前缀名
+// param: tagName tag名
+// result: result如果不为struct的指针返回错误
+func BindFlagsWithTagName(fs *flag.FlagSet, result interface{}, tagPxName, tagName string) error {
+	t := reflect.TypeOf(result)
+	if t == nil || t.Kind() != reflect.Ptr {
+		return errors.New("result must be ptr")
+	}
+	t = t.Elem()
+	if t.Kind() != reflect.Struct {
+		return errors.New("result must be struct ptr")
+	}
+	f := newFiller(false, []string{tagPxName}, []string{tagName}, false)
+	return bindStruct(fs, f, t, "", map[reflect.Type]bool{})
+}
+
+// 与Fill相同的方式递归嵌套的struct（包括匿名嵌入的struct）
+// param: base 上层属性名
+func bindStruct(fs *flag.FlagSet, f *filler, t reflect.Type, base string, visited map[reflect.Type]bool) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if visited[t] {
+		return nil
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	prefix := make([]string, 1)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if f.isEmbedded(field) {
+			if err := bindStruct(fs, f, field.Type, base, visited); err != nil {
+				return err
+			}
+			continue
+		}
+		key, opts, ok := f.fieldKey(field, prefix)
+		if !ok {
+			continue
+		}
+		name := joinKey(base, key)
+		if f.isFillStruct(field.Type) {
+			if err := bindStruct(fs, f, field.Type, name, visited); err != nil {
+				return err
+			}
+			continue
+		}
+		if fs.Lookup(name) != nil {
+			continue
+		}
+		usage := field.Tag.Get(UsageTag)
+		if field.Type.Kind() == reflect.Bool {
+			def := false
+			if opts.hasDefault {
+				b, err := toBool(opts.defaultValue)
+				if err != nil {
+					return fmt.Errorf("field %s: %s", field.Name, err.Error())
+				}
+				def = b
+			}
+			fs.Bool(name, def, usage)
+		} else if field.Type.Kind() == reflect.String {
+			fs.String(name, opts.defaultValue, usage)
+		} else {
+			fs.Var(&scalarFlag{value: opts.defaultValue}, name, usage)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package yfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+type Format struct {
+	// 格式名称，如"yaml"
+	Name string
+	// 文件扩展名，如".yaml"，不区分大小写
+	Extensions []string
+	NewReader  func() ValueReader
+	NewLoader  func() ValueLoader
+	// 内容嗅探，扩展名无法识别时按注册顺序调用，返回true表示内容为该格式，为nil时不参与嗅探
+	Sniff func(data []byte) bool
+}
+
+var (
+	formats     []*Format
+	formatsLock sync.RWMutex
+)
+
+func init() {
+	yamlLoader := func() ValueLoader { return NewYamlLoader() }
+	builtin := []Format{
+		{
+			Name:       "json",
+			Extensions: []string{".json"},
+			NewReader:  func() ValueReader { return NewJsonReader() },
+			NewLoader:  func() ValueLoader { return NewJsonLoader() },
+			Sniff:      sniffJson,
+		},
+		{
+			Name:       "toml",
+			Extensions: []string{".toml"},
+			NewReader:  func() ValueReader { return NewTomlReader() },
+			NewLoader:  func() ValueLoader { return NewTomlLoader() },
+			Sniff:      sniffReader(NewTomlReader()),
+		},
+		{
+			Name:       "yaml",
+			Extensions: []string{".yaml", ".yml"},
+			NewReader:  func() ValueReader { return NewYamlReader() },
+			NewLoader:  yamlLoader,
+			Sniff:      sniffReader(NewYamlReader()),
+		},
+		{
+			Name:       "properties",
+			Extensions: []string{".properties"},
+			NewReader:  func() ValueReader { return NewPropertiesReader() },
+			NewLoader:  yamlLoader,
+		},
+		{
+			Name:       "ini",
+			Extensions: []string{".ini"},
+			NewReader:  func() ValueReader { return NewIniReader() },
+			NewLoader:  yamlLoader,
+		},
+		{
+			Name:       "dotenv",
+			Extensions: []string{".env"},
+			NewReader:  func() ValueReader { return NewDotenvReader() },
+			NewLoader:  yamlLoader,
+		},
+	}
+	for i := range builtin {
+		if err := RegisterFormat(builtin[i]); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// 注册文件格式，同名格式会被替换（保持原有的嗅探顺序）
+// param: f 格式定义，Name、NewReader、NewLoader不能为空
+func RegisterFormat(f Format) error {
+	if f.Name == "" || f.NewReader == nil || f.NewLoader == nil {
+		return errors.New("format name, reader and loader must not be empty")
+	}
+	exts := make([]string, len(f.Extensions))
+	for i, ext := range f.Extensions {
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		exts[i] = strings.ToLower(ext)
+	}
+	f.Extensions = exts
+
+	formatsLock.Lock()
+	defer formatsLock.Unlock()
+
+	for i := range formats {
+		if formats[i].Name == f.Name {
+			formats[i] = &f
+			return nil
+		}
+	}
+	formats = append(formats, &f)
+	return nil
+}
+
+// param: name 格式名称
+// return: 是否存在该格式
+func UnregisterFormat(name string) bool {
+	formatsLock.Lock()
+	defer formatsLock.Unlock()
+
+	for i := range formats {
+		if formats[i].Name == name {
+			formats = append(formats[:i:i], formats[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// param: name 格式名称
+// return: 已注册的格式，不存在返回false
+func LookupFormat(name string) (Format, bool) {
+	formatsLock.RLock()
+	defer formatsLock.RUnlock()
+
+	for _, f := range formats {
+		if f.Name == name {
+			return *f, true
+		}
+	}
+	return Format{}, false
+}
+
+// param: filename 文件名
+// return: 扩展名对应的格式，后注册的格式优先，不存在返回false
+func LookupFormatByFile(filename string) (Format, bool) {
+	ext := strings.ToLower(filepath.Ext(filename))
+
+	formatsLock.RLock()
+	defer formatsLock.RUnlock()
+
+	for i := len(formats) - 1; i >= 0; i-- {
+		for _, e := range formats[i].Extensions {
+			if e == ext {
+				return *formats[i], true
+			}
+		}
+	}
+	return Format{}, false
+}
+
+// param: data 文件内容
+// return: 按注册顺序第一个嗅探成功的格式，不存在返回false
+func SniffFormat(data []byte) (Format, bool) {
+	formatsLock.RLock()
+	list := append([]*Format(nil), formats...)
+	formatsLock.RUnlock()
+
+	for _, f := range list {
+		if f.Sniff != nil && f.Sniff(data) {
+			return *f, true
+		}
+	}
+	return Format{}, false
+}
+
+func sniffJson(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] == '{' && json.Valid(data)
+}
+
+// 使用reader解析成功即认为是该格式
+func sniffReader(reader ValueReader) func(data []byte) bool {
+	return func(data []byte) bool {
+		if len(bytes.TrimSpace(data)) == 0 {
+			return false
+		}
+		_, err := reader.Read(bytes.NewReader(data))
+		return err == nil
+	}
+}
+
+// 根据扩展名选择ValueReader和ValueLoader加载文件，扩展名无法识别时根据内容（执行模板后）嗅探格式
+// param: filename 文件名
+// param: opts New的参数
+// return: 属性，格式无法识别时返回错误
+func Load(filename string, opts ...Opt) (Properties, error) {
+	if f, ok := LookupFormatByFile(filename); ok {
+		return LoadFile(filename, f.NewReader(), f.NewLoader(), opts...)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	prop := New(opts...)
+	if prop == nil {
+		return nil, errors.New("create properties failed")
+	}
+	if err := prop.refreshEnv(); err != nil {
+		return nil, err
+	}
+	r, err := prop.ExecTemplate(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	f, ok := SniffFormat(content)
+	if !ok {
+		return nil, fmt.Errorf("unknown format of file %s", filename)
+	}
+	prop.SetValueReader(f.NewReader())
+	prop.SetValueLoader(f.NewLoader())
+	err = prop.ReadValue(bytes.NewReader(data))
+	return prop, err
+}
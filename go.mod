@@ -7,4 +7,4 @@ require (
 	github.com/ydx1011/reflection v0.0.1
 )
 
-require gopkg.in/yaml.v2 v2.4.0 // indirect
+require gopkg.in/yaml.v2 v2.4.0
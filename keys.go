@@ -0,0 +1,133 @@
+package yfig
+
+import "errors"
+
+// Walk的回调方法
+// param: key 属性名，可以直接用于Get、GetValue
+// param: value 属性值（标量、nil或空的map、列表）
+// return: 返回错误时停止遍历，Walk返回该错误
+type WalkFunc func(key string, value interface{}) error
+
+// param: key 属性名
+// return: 属性是否存在，值为null的属性也视为存在
+func (ctx *DefaultProperties) Has(key string) bool {
+	return ctx.current().has(key)
+}
+
+// param: prefix 属性前缀，为空时返回全部属性名
+// return: prefix下全部叶子节点的属性名（按名称排序，列表元素为"a[0]"格式）
+func (ctx *DefaultProperties) Keys(prefix string) []string {
+	return ctx.current().keys("", prefix)
+}
+
+// param: prefix 属性前缀，为空时返回根节点的子节点
+// return: prefix下一级的名称，map按名称排序，列表为"[0]"、"[1]"...，prefix不存在或为标量时返回nil
+func (ctx *DefaultProperties) Children(prefix string) []string {
+	return ctx.current().children("", prefix)
+}
+
+// 按属性名顺序深度优先遍历全部叶子节点
+func (ctx *DefaultProperties) Walk(fn WalkFunc) error {
+	return ctx.current().walk("", "", fn)
+}
+
+// return: 全部属性值的副本，修改不影响属性
+func (ctx *DefaultProperties) AllSettings() Value {
+	return ctx.current().settings("")
+}
+
+func (s *propState) has(key string) bool {
+	_, err := lookupPath(s.value, key)
+	return err == nil
+}
+
+func (s *propState) keys(root, prefix string) []string {
+	var ret []string
+	s.walk(root, prefix, func(key string, value interface{}) error {
+		ret = append(ret, key)
+		return nil
+	})
+	return ret
+}
+
+func (s *propState) children(root, prefix string) []string {
+	node, err := lookupPath(s.value, subKey(root, prefix))
+	if err != nil {
+		return nil
+	}
+	switch o := walkable(node).(type) {
+	case map[string]interface{}:
+		ret := make([]string, 0, len(o))
+		for _, k := range sortedKeys(o) {
+			ret = append(ret, appendSegment("", pathSegment{key: k}))
+		}
+		return ret
+	case []interface{}:
+		ret := make([]string, 0, len(o))
+		for i := range o {
+			ret = append(ret, appendSegment("", pathSegment{index: i, isIndex: true}))
+		}
+		return ret
+	}
+	return nil
+}
+
+// param: root 视图的属性前缀
+// param: prefix 相对于root的属性前缀，回调中的属性名以prefix开头
+func (s *propState) walk(root, prefix string, fn WalkFunc) error {
+	node, err := lookupPath(s.value, subKey(root, prefix))
+	if err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			return nil
+		}
+		return err
+	}
+	return walkNode(node, prefix, fn)
+}
+
+func (s *propState) settings(root string) Value {
+	node, err := lookupPath(s.value, root)
+	if err != nil {
+		return Value{}
+	}
+	// normalizeValue会复制map及列表
+	if m, ok := normalizeValue(node).(map[string]interface{}); ok {
+		return m
+	}
+	return Value{}
+}
+
+func walkNode(node interface{}, key string, fn WalkFunc) error {
+	switch o := walkable(node).(type) {
+	case map[string]interface{}:
+		if len(o) == 0 {
+			break
+		}
+		for _, k := range sortedKeys(o) {
+			if err := walkNode(o[k], appendSegment(key, pathSegment{key: k}), fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		if len(o) == 0 {
+			break
+		}
+		for i := range o {
+			if err := walkNode(o[i], appendSegment(key, pathSegment{index: i, isIndex: true}), fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fn(key, node)
+}
+
+// 非map[string]interface{}、[]interface{}的容器（如WithValue指定的map[string]string）转换后再遍历
+func walkable(node interface{}) interface{} {
+	switch node.(type) {
+	case map[string]interface{}, []interface{}, nil:
+		return node
+	}
+	return normalizeValue(node)
+}
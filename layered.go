@@ -0,0 +1,319 @@
+package yfig
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+type Source interface {
+	// 数据源名称，用于记录属性来源
+	Name() string
+	// param: ctx 属性，用于执行模板
+	// return: 读取的属性值
+	Load(ctx *DefaultProperties) (*Value, error)
+}
+
+type FileSource struct {
+	Filename string
+	// 为nil时根据扩展名从已注册的格式中选择
+	Reader ValueReader
+	// 为true时文件不存在不返回错误
+	Optional bool
+}
+
+func NewFileSource(filename string, reader ValueReader) *FileSource {
+	return &FileSource{
+		Filename: filename,
+		Reader:   reader,
+	}
+}
+
+func NewOptionalFileSource(filename string, reader ValueReader) *FileSource {
+	return &FileSource{
+		Filename: filename,
+		Reader:   reader,
+		Optional: true,
+	}
+}
+
+func (s *FileSource) Name() string {
+	return s.Filename
+}
+
+func (s *FileSource) Load(ctx *DefaultProperties) (*Value, error) {
+	f, err := os.Open(s.Filename)
+	if err != nil {
+		if s.Optional && os.IsNotExist(err) {
+			return &Value{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	reader := s.Reader
+	if reader == nil {
+		format, ok := LookupFormatByFile(s.Filename)
+		if !ok {
+			return nil, fmt.Errorf("unknown format of file %s", s.Filename)
+		}
+		reader = format.NewReader()
+	}
+	return ctx.parseValue(f, reader)
+}
+
+type ReaderSource struct {
+	name   string
+	r      io.Reader
+	reader ValueReader
+
+	data []byte
+	once sync.Once
+	err  error
+}
+
+// 首次Load时读取r的全部内容并保存，之后重复Load使用保存的内容
+func NewReaderSource(name string, r io.Reader, reader ValueReader) *ReaderSource {
+	return &ReaderSource{
+		name:   name,
+		r:      r,
+		reader: reader,
+	}
+}
+
+func (s *ReaderSource) Name() string {
+	return s.name
+}
+
+func (s *ReaderSource) Load(ctx *DefaultProperties) (*Value, error) {
+	s.once.Do(func() {
+		s.data, s.err = io.ReadAll(s.r)
+		s.r = nil
+	})
+	if s.err != nil {
+		return nil, s.err
+	}
+	return ctx.parseValue(bytes.NewReader(s.data), s.reader)
+}
+
+type MapSource struct {
+	name  string
+	value Value
+}
+
+func NewMapSource(name string, value Value) *MapSource {
+	return &MapSource{
+		name:  name,
+		value: value,
+	}
+}
+
+func (s *MapSource) Name() string {
+	return s.name
+}
+
+func (s *MapSource) Load(ctx *DefaultProperties) (*Value, error) {
+	ret := copyValue(s.value).(map[string]interface{})
+	return &ret, nil
+}
+
+const (
+	// SetDefault设置的属性的来源
+	DefaultOrigin = "default"
+	// Set设置的属性的来源
+	SetOrigin = "set"
+)
+
+// 多层属性，按添加顺序合并，后添加的数据源优先级更高，合并规则见MergeValue
+type LayeredProperties struct {
+	*DefaultProperties
+
+	sources []Source
+	// ReadValue添加的数据源，再次ReadValue时替换
+	readerSource Source
+	origins      map[string]string
+	mu           sync.Mutex
+}
+
+func NewLayered(sources ...Source) *LayeredProperties {
+	return NewLayeredWithOpts(sources, nil)
+}
+
+func NewLayeredWithOpts(sources []Source, opts []Opt) *LayeredProperties {
+	prop := New(opts...)
+	if prop == nil {
+		return nil
+	}
+	return &LayeredProperties{
+		DefaultProperties: prop,
+		sources:           sources,
+		origins:           map[string]string{},
+	}
+}
+
+// param: sources 数据源，优先级从低到高
+// return: 加载完成的属性，加载失败返回错误
+func LoadLayered(sources ...Source) (*LayeredProperties, error) {
+	prop := NewLayered(sources...)
+	err := prop.Load()
+	return prop, err
+}
+
+// 添加优先级最高的数据源，需要调用Load生效
+func (ctx *LayeredProperties) AddSource(s Source) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	ctx.sources = append(ctx.sources, s)
+}
+
+// 从r读取属性值作为优先级最高的一层并重新合并，替换之前ReadValue读取的一层
+// 加载失败时保留原有的数据源及属性值
+func (ctx *LayeredProperties) ReadValue(r io.Reader) error {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	sources := make([]Source, 0, len(ctx.sources)+1)
+	for _, s := range ctx.sources {
+		if s != ctx.readerSource {
+			sources = append(sources, s)
+		}
+	}
+	s := NewReaderSource(fmt.Sprintf("reader#%d", len(sources)), r, ctx.reader)
+	sources = append(sources, s)
+	if err := ctx.loadLocked(sources); err != nil {
+		return err
+	}
+	ctx.sources = sources
+	ctx.readerSource = s
+	return nil
+}
+
+func (ctx *LayeredProperties) Sources() []Source {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	return append([]Source(nil), ctx.sources...)
+}
+
+// 依次读取所有数据源并合并，任一数据源失败时保留原有属性值并返回错误
+func (ctx *LayeredProperties) Load() error {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	return ctx.loadLocked(ctx.sources)
+}
+
+// 需持有mu
+func (ctx *LayeredProperties) loadLocked(sources []Source) error {
+	if err := ctx.refreshEnv(); err != nil {
+		return err
+	}
+	value := Value{}
+	origins := map[string]string{}
+	for _, s := range sources {
+		var v *Value
+		var err error
+		if o, ok := s.(OverlaySource); ok {
+			v, err = o.Overlay(ctx.DefaultProperties, value)
+		} else {
+			v, err = s.Load(ctx.DefaultProperties)
+		}
+		if err != nil {
+			return fmt.Errorf("load source %s failed: %s", s.Name(), err.Error())
+		}
+		if v == nil {
+			continue
+		}
+		name := s.Name()
+		mergeValue(value, *v, "", func(key string, replaced bool) {
+			if replaced {
+				deleteOrigins(origins, key)
+			}
+			origins[key] = name
+		})
+	}
+	err := ctx.applyOverlays(&value, func(name, key string, replaced bool) {
+		if replaced {
+			deleteOrigins(origins, key)
+		}
+		origins[key] = name
+	})
+	if err != nil {
+		return err
+	}
+	ctx.applyDefaults(&value, func(key string) {
+		origins[key] = DefaultOrigin
+	})
+	value, encrypted, err := ctx.decryptValue(value)
+	if err != nil {
+		return err
+	}
+	if err := ctx.checkRefs(&value); err != nil {
+		return err
+	}
+	ctx.origins = origins
+	ctx.setValue(&value, encrypted)
+	return nil
+}
+
+// 设置属性值，Origin返回SetOrigin
+func (ctx *LayeredProperties) Set(key string, value interface{}) error {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	if err := ctx.DefaultProperties.Set(key, value); err != nil {
+		return err
+	}
+	deleteOrigins(ctx.origins, key)
+	ctx.origins[key] = SetOrigin
+	return nil
+}
+
+func (ctx *LayeredProperties) Delete(key string) error {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	if err := ctx.DefaultProperties.Delete(key); err != nil {
+		return err
+	}
+	deleteOrigins(ctx.origins, key)
+	delete(ctx.origins, key)
+	return nil
+}
+
+// 设置默认值，使用了默认值的属性Origin返回DefaultOrigin
+func (ctx *LayeredProperties) SetDefault(key string, value interface{}) error {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	had := ctx.Has(key)
+	if err := ctx.DefaultProperties.SetDefault(key, value); err != nil {
+		return err
+	}
+	if !had && ctx.Has(key) {
+		ctx.origins[key] = DefaultOrigin
+	}
+	return nil
+}
+
+// param: key 属性名称
+// return: 设置该属性的优先级最高的数据源名称，属性不存在返回false
+func (ctx *LayeredProperties) Origin(key string) (string, bool) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	name, ok := ctx.origins[key]
+	return name, ok
+}
+
+func deleteOrigins(origins map[string]string, key string) {
+	prefix := key + "."
+	for k := range origins {
+		if strings.HasPrefix(k, prefix) {
+			delete(origins, k)
+		}
+	}
+}
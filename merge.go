@@ -0,0 +1,61 @@
+package yfig
+
+// 合并规则：
+// map: 递归合并，src中的key覆盖dst中的同名key
+// 列表: src整体替换dst，不做元素级合并
+// 标量（包括null）: src替换dst
+// 类型不一致时src替换dst
+// param: dst 被合并的属性，会被修改
+// param: src 优先级更高的属性，不会被修改
+func MergeValue(dst, src Value) {
+	mergeValue(dst, src, "", nil)
+}
+
+// onSet在每个被src设置的key（含中间map节点）上调用，replaced表示原有的子节点已被整体替换
+func mergeValue(dst, src Value, prefix string, onSet func(key string, replaced bool)) {
+	for k, sv := range src {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if sm, ok := sv.(map[string]interface{}); ok {
+			if dm, ok := dst[k].(map[string]interface{}); ok {
+				if onSet != nil {
+					onSet(key, false)
+				}
+				mergeValue(dm, sm, key, onSet)
+				continue
+			}
+			if onSet != nil {
+				onSet(key, true)
+			}
+			dm := Value{}
+			mergeValue(dm, sm, key, onSet)
+			dst[k] = dm
+			continue
+		}
+		if onSet != nil {
+			onSet(key, true)
+		}
+		dst[k] = copyValue(sv)
+	}
+}
+
+// 深拷贝map与列表，标量原样返回
+func copyValue(v interface{}) interface{} {
+	switch o := v.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(o))
+		for k, v := range o {
+			ret[k] = copyValue(v)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(o))
+		for i := range o {
+			ret[i] = copyValue(o[i])
+		}
+		return ret
+	}
+	return v
+}
@@ -0,0 +1,266 @@
+package yfig
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// 属性路径中的一段，名称或列表下标
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+func (s pathSegment) String() string {
+	if s.isIndex {
+		return "[" + strconv.Itoa(s.index) + "]"
+	}
+	return s.key
+}
+
+// 解析属性路径，空字符串表示根节点：
+// a.b-c: 以"."分隔，名称可以包含除"."、"["及引号外的任意字符
+// servers[0].host: [N]表示列表下标
+// "a.b".c、a["b.c"]: 引号（单引号或双引号）内为完整的名称，可以包含"."，引号本身使用反斜杠转义
+func parsePath(key string) ([]pathSegment, error) {
+	var ret []pathSegment
+	i := 0
+	for i < len(key) {
+		switch c := key[i]; {
+		case c == '"' || c == '\'':
+			s, n, err := parseQuotedKey(key[i:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid key %s: %s", key, err.Error())
+			}
+			ret = append(ret, pathSegment{key: s})
+			i += n
+		case c == '[':
+			end := strings.IndexByte(key[i:], ']')
+			if i+1 < len(key) && (key[i+1] == '"' || key[i+1] == '\'') {
+				s, n, err := parseQuotedKey(key[i+1:])
+				if err != nil || i+1+n >= len(key) || key[i+1+n] != ']' {
+					return nil, fmt.Errorf("invalid key %s: unterminated [", key)
+				}
+				ret = append(ret, pathSegment{key: s})
+				i += n + 2
+				break
+			}
+			if end == -1 {
+				return nil, fmt.Errorf("invalid key %s: unterminated [", key)
+			}
+			index, err := strconv.Atoi(key[i+1 : i+end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid key %s: invalid index %s", key, key[i+1:i+end])
+			}
+			ret = append(ret, pathSegment{index: index, isIndex: true})
+			i += end + 1
+		default:
+			start := i
+			for i < len(key) && key[i] != '.' && key[i] != '[' && key[i] != '"' && key[i] != '\'' {
+				i++
+			}
+			if start == i {
+				return nil, fmt.Errorf("invalid key %s: empty name", key)
+			}
+			ret = append(ret, pathSegment{key: key[start:i]})
+		}
+		if i >= len(key) {
+			break
+		}
+		switch key[i] {
+		case '.':
+			i++
+			if i >= len(key) {
+				return nil, fmt.Errorf("invalid key %s: empty name", key)
+			}
+		case '[':
+		default:
+			return nil, fmt.Errorf("invalid key %s: unexpected %q", key, key[i])
+		}
+	}
+	return ret, nil
+}
+
+// 解析以引号开头的名称
+// return: 名称，包含引号的长度
+func parseQuotedKey(s string) (string, int, error) {
+	quote := s[0]
+	buf := strings.Builder{}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+			}
+			buf.WriteByte(s[i])
+		case quote:
+			return buf.String(), i + 1, nil
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated quote")
+}
+
+// 在属性树中查找key对应的节点
+// return: 节点，key格式错误或不存在时返回错误（不存在时包装ErrKeyNotFound）
+func lookupPath(v *Value, key string) (interface{}, error) {
+	path, err := parsePath(key)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+	}
+	var node interface{} = *v
+	for i, seg := range path {
+		next, ok := childOf(node, seg)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s (at %s)", ErrKeyNotFound, key, joinSegments(path[:i+1]))
+		}
+		node = next
+	}
+	return node, nil
+}
+
+func childOf(node interface{}, seg pathSegment) (interface{}, bool) {
+	switch o := node.(type) {
+	case map[string]interface{}:
+		if seg.isIndex {
+			return nil, false
+		}
+		ret, ok := o[seg.key]
+		return ret, ok
+	case []interface{}:
+		if !seg.isIndex || seg.index >= len(o) {
+			return nil, false
+		}
+		return o[seg.index], true
+	case nil:
+		return nil, false
+	}
+
+	rv := reflect.ValueOf(node)
+	switch rv.Kind() {
+	case reflect.Map:
+		if seg.isIndex || rv.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		ret := rv.MapIndex(reflect.ValueOf(seg.key).Convert(rv.Type().Key()))
+		if !ret.IsValid() {
+			return nil, false
+		}
+		return ret.Interface(), true
+	case reflect.Slice, reflect.Array:
+		if !seg.isIndex || seg.index >= rv.Len() {
+			return nil, false
+		}
+		return rv.Index(seg.index).Interface(), true
+	}
+	return nil, false
+}
+
+func joinSegments(path []pathSegment) string {
+	ret := ""
+	for _, seg := range path {
+		ret = appendSegment(ret, seg)
+	}
+	return ret
+}
+
+// 在属性名key后拼接一段路径，包含"."、"["或引号的名称使用引号
+func appendSegment(key string, seg pathSegment) string {
+	if seg.isIndex {
+		return key + seg.String()
+	}
+	name := seg.key
+	if name == "" || strings.ContainsAny(name, `.["'`) {
+		name = strconv.Quote(name)
+	}
+	return joinKey(key, name)
+}
+
+// 不经过ValueLoader直接将节点赋值给v，支持interface{}、基础类型（节点为标量）、
+// map[string]interface{}（节点为map）、[]interface{}（节点为列表）
+// return: 是否已处理，处理时转换失败返回错误
+func assignNode(v reflect.Value, node interface{}) (bool, error) {
+	if node == nil {
+		return false, nil
+	}
+	t := v.Type()
+	_, isMap := node.(map[string]interface{})
+	_, isList := node.([]interface{})
+	scalar := false
+	switch node.(type) {
+	case string, bool, float64, float32, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		scalar = true
+	}
+
+	var err error
+	switch t.Kind() {
+	case reflect.Interface:
+		if t.NumMethod() != 0 {
+			return false, nil
+		}
+		v.Set(reflect.ValueOf(normalizeValue(node)))
+		return true, nil
+	case reflect.Map:
+		if !isMap || t != reflect.TypeOf(map[string]interface{}{}) {
+			return false, nil
+		}
+		v.Set(reflect.ValueOf(normalizeValue(node)))
+		return true, nil
+	case reflect.Slice:
+		if !isList || t != reflect.TypeOf([]interface{}{}) {
+			return false, nil
+		}
+		v.Set(reflect.ValueOf(normalizeValue(node)))
+		return true, nil
+	case reflect.String:
+		if !scalar {
+			return false, nil
+		}
+		var s string
+		if s, err = toString(node); err == nil {
+			v.SetString(s)
+		}
+	case reflect.Bool:
+		if !scalar {
+			return false, nil
+		}
+		var b bool
+		if b, err = toBool(node); err == nil {
+			v.SetBool(b)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !scalar {
+			return false, nil
+		}
+		var i int64
+		if i, err = toInt64(node, t.Bits()); err == nil {
+			v.SetInt(i)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if !scalar {
+			return false, nil
+		}
+		var u uint64
+		if u, err = toUint64(node, t.Bits()); err == nil {
+			v.SetUint(u)
+		}
+	case reflect.Float32, reflect.Float64:
+		if !scalar {
+			return false, nil
+		}
+		var f float64
+		if f, err = toFloat64(node, t.Bits()); err == nil {
+			v.SetFloat(f)
+		}
+	default:
+		return false, nil
+	}
+	return true, err
+}
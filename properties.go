@@ -21,6 +21,13 @@ type ValueLoader interface {
 	Deserializer
 }
 
+// ValueLoader可选实现的接口，WriteTo使用原始内容序列化属性值，以保留注释、key的顺序等
+type DocumentSerializer interface {
+	// param: source ReadValue读取的原始内容
+	// param: v 当前的属性值
+	SerializeDocument(source []byte, v interface{}) (string, error)
+}
+
 type Properties interface {
 	// 配置ValueReader
 	SetValueReader(r ValueReader)
@@ -36,4 +43,22 @@ type Properties interface {
 	// param: result: 填充对象指针
 	// return: 正常返回nil,否则返回错误
 	GetValue(key string, result interface{}) error
+	// return: 当前属性值的不可变快照，之后重新加载属性不影响快照的读取结果
+	Snapshot() *Snapshot
+	// param: prefix 属性前缀
+	// return: 以prefix为根的属性视图，Get、GetValue的key为相对于prefix的属性名
+	Sub(prefix string) Properties
+	// 订阅prefix下属性的变更
+	// return: 取消订阅的方法
+	OnChange(prefix string, fn ChangeFunc) func()
+	// return: 属性是否存在
+	Has(key string) bool
+	// return: prefix下全部叶子节点的属性名
+	Keys(prefix string) []string
+	// return: prefix下一级的名称
+	Children(prefix string) []string
+	// 遍历全部叶子节点
+	Walk(fn WalkFunc) error
+	// return: 全部属性值的副本
+	AllSettings() Value
 }
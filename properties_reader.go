@@ -0,0 +1,301 @@
+package yfig
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Java .properties格式的ValueReader，带"."的key转换为嵌套属性（a.b.c=1对应Get("a.b.c")）
+// 支持#、!注释，=、:、空白分隔符，行尾反斜杠续行，\t、\n、\uXXXX等转义
+// 值为true/false或十进制数字时转换为对应类型，否则为字符串；重复的key以最后一个为准
+type PropertiesReader struct{}
+
+func NewPropertiesReader() *PropertiesReader {
+	return &PropertiesReader{}
+}
+
+// INI格式的ValueReader，[a.b]节及带"."的key转换为嵌套属性
+// 支持;、#注释（行首或前面带空白的行内注释），=、:分隔符，行尾反斜杠续行，单双引号包围的值
+// 值的类型转换与PropertiesReader一致；重复的key以最后一个为准
+type IniReader struct{}
+
+func NewIniReader() *IniReader {
+	return &IniReader{}
+}
+
+func (v *PropertiesReader) Read(r io.Reader) (*Value, error) {
+	ret := Value{}
+	lines, err := readLogicalLines(r, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range lines {
+		key, value := splitPropertiesLine(l.text)
+		key, err = unescapeProperties(key)
+		if err != nil {
+			return nil, fmt.Errorf("properties: line %d: %s", l.no, err.Error())
+		}
+		value, err = unescapeProperties(value)
+		if err != nil {
+			return nil, fmt.Errorf("properties: line %d: %s", l.no, err.Error())
+		}
+		if err := setDottedKey(ret, strings.Split(key, "."), parseScalar(value)); err != nil {
+			return nil, fmt.Errorf("properties: line %d: %s", l.no, err.Error())
+		}
+	}
+	return &ret, nil
+}
+
+func (v *IniReader) Read(r io.Reader) (*Value, error) {
+	ret := Value{}
+	lines, err := readLogicalLines(r, false)
+	if err != nil {
+		return nil, err
+	}
+	var section []string
+	for _, l := range lines {
+		text := l.text
+		if text[0] == '[' {
+			end := strings.Index(text, "]")
+			if end == -1 || !isIniComment(strings.TrimSpace(text[end+1:])) {
+				return nil, fmt.Errorf("ini: line %d: invalid section %s", l.no, text)
+			}
+			name := strings.TrimSpace(text[1:end])
+			if name == "" {
+				return nil, fmt.Errorf("ini: line %d: empty section name", l.no)
+			}
+			section = strings.Split(name, ".")
+			for i := range section {
+				section[i] = strings.TrimSpace(section[i])
+			}
+			if _, err := ensureMap(ret, section); err != nil {
+				return nil, fmt.Errorf("ini: line %d: %s", l.no, err.Error())
+			}
+			continue
+		}
+		i := strings.IndexAny(text, "=:")
+		if i <= 0 {
+			return nil, fmt.Errorf("ini: line %d: expect key=value but get %s", l.no, text)
+		}
+		key := strings.TrimSpace(text[:i])
+		value, err := parseIniValue(strings.TrimSpace(text[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("ini: line %d: %s", l.no, err.Error())
+		}
+		path := append(append([]string(nil), section...), strings.Split(key, ".")...)
+		if err := setDottedKey(ret, path, value); err != nil {
+			return nil, fmt.Errorf("ini: line %d: %s", l.no, err.Error())
+		}
+	}
+	return &ret, nil
+}
+
+type logicalLine struct {
+	no   int
+	text string
+}
+
+// 读取去除注释、空行并处理续行后的逻辑行，properties为true时使用properties格式的注释符
+func readLogicalLines(r io.Reader, properties bool) ([]logicalLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var ret []logicalLine
+	buf := strings.Builder{}
+	start := 0
+	no := 0
+	for scanner.Scan() {
+		no++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if buf.Len() == 0 {
+			line = strings.TrimLeft(line, " \t\f")
+			if line == "" || line[0] == '#' || (properties && line[0] == '!') || (!properties && line[0] == ';') {
+				continue
+			}
+			start = no
+		} else {
+			line = strings.TrimLeft(line, " \t\f")
+		}
+		if endsWithContinuation(line) {
+			buf.WriteString(line[:len(line)-1])
+			continue
+		}
+		buf.WriteString(line)
+		ret = append(ret, logicalLine{no: start, text: buf.String()})
+		buf.Reset()
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if buf.Len() > 0 {
+		ret = append(ret, logicalLine{no: start, text: buf.String()})
+	}
+	return ret, nil
+}
+
+// 行尾有奇数个反斜杠时表示续行
+func endsWithContinuation(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// 按第一个未转义的=、:或空白分割key和value
+func splitPropertiesLine(line string) (string, string) {
+	i := 0
+	for i < len(line) {
+		c := line[i]
+		if c == '\\' {
+			i += 2
+			continue
+		}
+		if c == '=' || c == ':' || c == ' ' || c == '\t' || c == '\f' {
+			break
+		}
+		i++
+	}
+	if i >= len(line) {
+		return line, ""
+	}
+	key := line[:i]
+	rest := strings.TrimLeft(line[i:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	return key, rest
+}
+
+func unescapeProperties(s string) (string, error) {
+	if strings.IndexByte(s, '\\') == -1 {
+		return s, nil
+	}
+	buf := strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			buf.WriteByte(c)
+			continue
+		}
+		i++
+		if i >= len(s) {
+			break
+		}
+		switch s[i] {
+		case 't':
+			buf.WriteByte('\t')
+		case 'n':
+			buf.WriteByte('\n')
+		case 'r':
+			buf.WriteByte('\r')
+		case 'f':
+			buf.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("invalid unicode escape")
+			}
+			code, err := strconv.ParseUint(s[i+1:i+5], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape \\u%s", s[i+1:i+5])
+			}
+			buf.WriteRune(rune(code))
+			i += 4
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+	return buf.String(), nil
+}
+
+func isIniComment(s string) bool {
+	return s == "" || s[0] == ';' || s[0] == '#'
+}
+
+func parseIniValue(s string) (interface{}, error) {
+	if s == "" {
+		return "", nil
+	}
+	if s[0] == '"' || s[0] == '\'' {
+		quote := s[0]
+		buf := strings.Builder{}
+		for i := 1; i < len(s); i++ {
+			c := s[i]
+			if c == quote {
+				if !isIniComment(strings.TrimSpace(s[i+1:])) {
+					return nil, fmt.Errorf("unexpected content after quoted value: %s", s)
+				}
+				return buf.String(), nil
+			}
+			if c == '\\' && quote == '"' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					buf.WriteByte('\n')
+				case 't':
+					buf.WriteByte('\t')
+				case 'r':
+					buf.WriteByte('\r')
+				default:
+					buf.WriteByte(s[i])
+				}
+				continue
+			}
+			buf.WriteByte(c)
+		}
+		return nil, fmt.Errorf("unterminated quoted value: %s", s)
+	}
+	for i := 1; i < len(s); i++ {
+		if (s[i] == ';' || s[i] == '#') && (s[i-1] == ' ' || s[i-1] == '\t') {
+			s = strings.TrimSpace(s[:i])
+			break
+		}
+	}
+	return parseScalar(s), nil
+}
+
+// 同时作为值与嵌套属性前缀的key（如log=INFO与log.file=x.log），值保存在该子key下（Get("log._value")）
+const DottedValueKey = "_value"
+
+// 创建或进入path对应的map，已有值的节点转换为map，原值保存在DottedValueKey下
+// return: path对应的map
+func ensureMap(node map[string]interface{}, path []string) (map[string]interface{}, error) {
+	for _, k := range path {
+		if k == "" {
+			return nil, fmt.Errorf("empty key in %s", strings.Join(path, "."))
+		}
+		switch child := node[k].(type) {
+		case nil:
+			m := map[string]interface{}{}
+			node[k] = m
+			node = m
+		case map[string]interface{}:
+			node = child
+		default:
+			m := map[string]interface{}{DottedValueKey: child}
+			node[k] = m
+			node = m
+		}
+	}
+	return node, nil
+}
+
+// 设置嵌套属性，已有嵌套属性时值保存在DottedValueKey下（如a=1与a.b=2对应a._value=1、a.b=2，与顺序无关）
+func setDottedKey(node map[string]interface{}, path []string, value interface{}) error {
+	node, err := ensureMap(node, path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+	last := path[len(path)-1]
+	if last == "" {
+		return fmt.Errorf("empty key in %s", strings.Join(path, "."))
+	}
+	if m, ok := node[last].(map[string]interface{}); ok {
+		m[DottedValueKey] = value
+		return nil
+	}
+	node[last] = value
+	return nil
+}
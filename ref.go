@@ -0,0 +1,241 @@
+package yfig
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrReferenceCycle = errors.New("reference cycle")
+
+// 解析属性值中的${key}引用：
+// 整个值为一个引用时替换为被引用的节点（保留类型，可以是map、列表），否则被引用的值必须为标量，格式化后拼接；
+// key为完整的属性路径（格式见parsePath），$${表示字符"${"，${secret:...}由SecretProvider解析
+type refResolver struct {
+	root      *Value
+	resolving map[string]bool
+	stack     []string
+	// 已解析的节点，属性名 -> 解析结果
+	memo map[string]interface{}
+}
+
+func newRefResolver(root *Value) *refResolver {
+	return &refResolver{
+		root:      root,
+		resolving: map[string]bool{},
+		memo:      map[string]interface{}{},
+	}
+}
+
+// 启用属性值中的${key}引用，未启用时${...}作为普通字符串
+func WithReferences() Opt {
+	return func(ctx *DefaultProperties) error {
+		ctx.refs = true
+		// WithValue指定的属性值同样生效
+		cur := ctx.current()
+		ctx.state.Store(&propState{value: cur.value, version: cur.version, secrets: ctx.secrets, refs: true})
+		return ctx.checkRefs(cur.value)
+	}
+}
+
+// 启用引用时检查v中的全部引用，引用不存在或循环引用时返回错误
+func (ctx *DefaultProperties) checkRefs(v *Value) error {
+	if !ctx.refs || v == nil || !hasRef(*v) {
+		return nil
+	}
+	r := newRefResolver(v)
+	for _, k := range sortedKeys(*v) {
+		if _, err := r.resolveAt(appendSegment("", pathSegment{key: k}), (*v)[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// param: key node的属性名（规范格式），用于循环检测及错误信息
+// return: 解析后的副本，node本身不修改
+func (r *refResolver) resolveAt(key string, node interface{}) (interface{}, error) {
+	if v, ok := r.memo[key]; ok {
+		return v, nil
+	}
+	if !hasRef(node) {
+		return node, nil
+	}
+	r.resolving[key] = true
+	r.stack = append(r.stack, key)
+	defer func() {
+		delete(r.resolving, key)
+		r.stack = r.stack[:len(r.stack)-1]
+	}()
+
+	var ret interface{}
+	var err error
+	switch o := node.(type) {
+	case string:
+		ret, err = r.resolveString(key, o)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(o))
+		for k, v := range o {
+			if m[k], err = r.resolveAt(appendSegment(key, pathSegment{key: k}), v); err != nil {
+				return nil, err
+			}
+		}
+		ret = m
+	case []interface{}:
+		list := make([]interface{}, len(o))
+		for i, v := range o {
+			if list[i], err = r.resolveAt(appendSegment(key, pathSegment{index: i, isIndex: true}), v); err != nil {
+				return nil, err
+			}
+		}
+		ret = list
+	default:
+		ret = node
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.memo[key] = ret
+	return ret, nil
+}
+
+func (r *refResolver) resolveString(key, s string) (interface{}, error) {
+	if ref, ok := wholeRef(s); ok {
+		return r.lookup(key, ref)
+	}
+	buf := strings.Builder{}
+	for {
+		start, end, ref, escaped := nextRef(s)
+		if start == -1 {
+			buf.WriteString(s)
+			return buf.String(), nil
+		}
+		if end == -1 {
+			return nil, fmt.Errorf("key %s: unterminated reference %s", key, s[start:])
+		}
+		switch {
+		case escaped:
+			buf.WriteString(s[:start])
+			buf.WriteString("${")
+			s = s[start+3:]
+			continue
+		case strings.HasPrefix(ref, "secret:"):
+			buf.WriteString(s[:end])
+			s = s[end:]
+			continue
+		}
+		v, err := r.lookup(key, ref)
+		if err != nil {
+			return nil, err
+		}
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("key %s: reference ${%s} is not a scalar value", key, ref)
+		}
+		buf.WriteString(s[:start])
+		if v != nil {
+			buf.WriteString(fmt.Sprint(v))
+		}
+		s = s[end:]
+	}
+}
+
+// param: key 引用所在的属性名
+// param: ref 被引用的属性名
+func (r *refResolver) lookup(key, ref string) (interface{}, error) {
+	path, err := parsePath(ref)
+	if err != nil {
+		return nil, fmt.Errorf("key %s: invalid reference ${%s}: %s", key, ref, err.Error())
+	}
+	target := joinSegments(path)
+	if r.resolving[target] {
+		return nil, fmt.Errorf("key %s: %w: %s", key, ErrReferenceCycle, strings.Join(append(r.stack, target), " -> "))
+	}
+	node, err := lookupPath(r.root, ref)
+	if err != nil {
+		return nil, fmt.Errorf("key %s: reference ${%s}: %w", key, ref, err)
+	}
+	return r.resolveAt(target, node)
+}
+
+// 查找s中第一个"${"
+// return: 开始位置（没有时为-1），结束位置（"}"之后，未结束时为-1），引用内容，是否为"$${"
+func nextRef(s string) (int, int, string, bool) {
+	start := strings.Index(s, "${")
+	if start == -1 {
+		return -1, -1, "", false
+	}
+	if start > 0 && s[start-1] == '$' {
+		return start - 1, start + 2, "", true
+	}
+	end := strings.IndexByte(s[start:], '}')
+	if end == -1 {
+		return start, -1, "", false
+	}
+	return start, start + end + 1, s[start+2 : start+end], false
+}
+
+// 整个字符串是否为一个引用
+func wholeRef(s string) (string, bool) {
+	start, end, ref, escaped := nextRef(s)
+	if start != 0 || end != len(s) || escaped || strings.HasPrefix(ref, "secret:") {
+		return "", false
+	}
+	return ref, true
+}
+
+// node中是否有需要解析的引用（包括"$${"）
+func hasRef(node interface{}) bool {
+	switch o := node.(type) {
+	case string:
+		for s := o; ; {
+			start, end, ref, escaped := nextRef(s)
+			if start == -1 {
+				return false
+			}
+			if escaped || end == -1 || !strings.HasPrefix(ref, "secret:") {
+				return true
+			}
+			s = s[end:]
+		}
+	case map[string]interface{}:
+		for _, v := range o {
+			if hasRef(v) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, v := range o {
+			if hasRef(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 解析node中的引用及敏感信息
+// param: key node的属性名
+// return: 解析后的节点，是否包含敏感信息（包含时结果不能缓存）
+func (s *propState) resolve(key string, node interface{}) (interface{}, bool, error) {
+	s.scanOnce.Do(func() {
+		if s.value != nil {
+			s.hasRefs = hasRef(*s.value)
+			s.hasSecrets = s.secrets != nil && hasSecretRef(*s.value)
+		}
+	})
+	if s.refs && s.hasRefs && hasRef(node) {
+		path, err := parsePath(key)
+		if err != nil {
+			return nil, false, err
+		}
+		if node, err = newRefResolver(s.value).resolveAt(joinSegments(path), node); err != nil {
+			return nil, false, err
+		}
+	}
+	if !s.hasSecrets || !hasSecretRef(node) {
+		return node, false, nil
+	}
+	ret, err := s.secrets.resolve(node)
+	return ret, true, err
+}
@@ -0,0 +1,250 @@
+package yfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 敏感信息缓存的默认有效期
+const DefaultSecretTTL = 5 * time.Minute
+
+const secretRefPrefix = "${secret:"
+
+// 敏感信息提供者，属性值中的${secret:scheme:ref}在读取时通过scheme对应的提供者解析
+type SecretProvider interface {
+	// param: ref 去掉scheme后的引用，如"/run/secrets/db"、"path#key"
+	// return: 敏感信息，不存在时返回错误
+	GetSecret(ref string) (string, error)
+}
+
+type SecretProviderFunc func(ref string) (string, error)
+
+func (f SecretProviderFunc) GetSecret(ref string) (string, error) {
+	return f(ref)
+}
+
+// 读取文件内容作为敏感信息（去掉末尾的换行），如Docker、Kubernetes挂载的secret文件
+type FileSecretProvider struct {
+	// ref为相对路径时的目录，为空时使用当前目录
+	Dir string
+}
+
+func (p *FileSecretProvider) GetSecret(ref string) (string, error) {
+	name := ref
+	if p.Dir != "" && !filepath.IsAbs(name) {
+		name = filepath.Join(p.Dir, name)
+	}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// 内存中的敏感信息，用于测试
+type MemorySecretProvider struct {
+	values map[string]string
+	lock   sync.RWMutex
+}
+
+func NewMemorySecretProvider(values map[string]string) *MemorySecretProvider {
+	ret := &MemorySecretProvider{values: map[string]string{}}
+	for k, v := range values {
+		ret.values[k] = v
+	}
+	return ret
+}
+
+func (p *MemorySecretProvider) Set(ref, value string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.values[ref] = value
+}
+
+func (p *MemorySecretProvider) GetSecret(ref string) (string, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	v, ok := p.values[ref]
+	if !ok {
+		return "", fmt.Errorf("secret %s not found", ref)
+	}
+	return v, nil
+}
+
+// 注册scheme对应的敏感信息提供者，同名时替换，内置file（FileSecretProvider）、env（环境变量）
+func WithSecretProvider(scheme string, p SecretProvider) Opt {
+	return func(ctx *DefaultProperties) error {
+		ctx.secrets.lock.Lock()
+		defer ctx.secrets.lock.Unlock()
+
+		ctx.secrets.providers[scheme] = p
+		return nil
+	}
+}
+
+// 设置敏感信息缓存的有效期，默认为DefaultSecretTTL，为0时每次读取都重新解析
+func WithSecretTTL(ttl time.Duration) Opt {
+	return func(ctx *DefaultProperties) error {
+		ctx.secrets.lock.Lock()
+		defer ctx.secrets.lock.Unlock()
+
+		ctx.secrets.ttl = ttl
+		return nil
+	}
+}
+
+type secretEntry struct {
+	value   string
+	expires time.Time
+}
+
+type secretResolver struct {
+	providers map[string]SecretProvider
+	ttl       time.Duration
+	// "scheme:ref" -> secretEntry
+	cache map[string]secretEntry
+	lock  sync.RWMutex
+}
+
+func newSecretResolver(ctx *DefaultProperties) *secretResolver {
+	return &secretResolver{
+		providers: map[string]SecretProvider{
+			"file": &FileSecretProvider{},
+			"env": SecretProviderFunc(func(ref string) (string, error) {
+				if v, ok := ctx.lookupEnv(ref); ok {
+					return v, nil
+				}
+				return "", fmt.Errorf("env %s not found", ref)
+			}),
+		},
+		ttl:   DefaultSecretTTL,
+		cache: map[string]secretEntry{},
+	}
+}
+
+// 模板函数secret，返回${secret:ref}，在读取属性时再解析
+func (r *secretResolver) templateFunc(ref string) (string, error) {
+	if _, _, err := r.provider(ref); err != nil {
+		return "", err
+	}
+	return secretRefPrefix + ref + "}", nil
+}
+
+// param: ref "scheme:ref"
+func (r *secretResolver) provider(ref string) (SecretProvider, string, error) {
+	i := strings.Index(ref, ":")
+	if i == -1 {
+		return nil, "", fmt.Errorf("invalid secret reference %s", ref)
+	}
+	r.lock.RLock()
+	p, ok := r.providers[ref[:i]]
+	r.lock.RUnlock()
+	if !ok {
+		return nil, "", fmt.Errorf("unknown secret provider %s", ref[:i])
+	}
+	return p, ref[i+1:], nil
+}
+
+// param: ref "scheme:ref"
+func (r *secretResolver) get(ref string) (string, error) {
+	r.lock.RLock()
+	e, ok := r.cache[ref]
+	ttl := r.ttl
+	r.lock.RUnlock()
+	if ok && time.Now().Before(e.expires) {
+		return e.value, nil
+	}
+
+	p, name, err := r.provider(ref)
+	if err != nil {
+		return "", err
+	}
+	v, err := p.GetSecret(name)
+	if err != nil {
+		return "", fmt.Errorf("resolve secret %s failed: %s", ref, err.Error())
+	}
+	if ttl > 0 {
+		r.lock.Lock()
+		r.cache[ref] = secretEntry{value: v, expires: time.Now().Add(ttl)}
+		r.lock.Unlock()
+	}
+	return v, nil
+}
+
+// return: 替换了全部${secret:...}的字符串
+func (r *secretResolver) resolveString(s string) (string, error) {
+	buf := strings.Builder{}
+	for {
+		start := strings.Index(s, secretRefPrefix)
+		if start == -1 {
+			buf.WriteString(s)
+			return buf.String(), nil
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end == -1 {
+			return "", fmt.Errorf("unterminated secret reference %s", s[start:])
+		}
+		v, err := r.get(s[start+len(secretRefPrefix) : start+end])
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(s[:start])
+		buf.WriteString(v)
+		s = s[start+end+1:]
+	}
+}
+
+// return: 替换了全部引用的副本，node本身不修改
+func (r *secretResolver) resolve(node interface{}) (interface{}, error) {
+	switch o := node.(type) {
+	case string:
+		return r.resolveString(o)
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(o))
+		for k, v := range o {
+			c, err := r.resolve(v)
+			if err != nil {
+				return nil, err
+			}
+			ret[k] = c
+		}
+		return ret, nil
+	case []interface{}:
+		ret := make([]interface{}, len(o))
+		for i := range o {
+			c, err := r.resolve(o[i])
+			if err != nil {
+				return nil, err
+			}
+			ret[i] = c
+		}
+		return ret, nil
+	}
+	return node, nil
+}
+
+func hasSecretRef(node interface{}) bool {
+	switch o := node.(type) {
+	case string:
+		return strings.Contains(o, secretRefPrefix)
+	case map[string]interface{}:
+		for _, v := range o {
+			if hasSecretRef(v) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, v := range o {
+			if hasSecretRef(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,288 @@
+package yfig
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// SetDefault设置的默认值
+type defaultValue struct {
+	key   string
+	path  []pathSegment
+	value interface{}
+}
+
+// 设置属性值，路径中不存在或不是map的节点替换为map，列表下标等于列表长度时追加元素
+// struct等非基础类型的值转换为map、列表等基础类型后保存
+// 设置的值在ReadValue等重新加载后失效，需要保留时使用SetDefault或覆盖数据源
+// param: key 属性名，格式见parsePath
+// return: key格式错误或列表下标越界时返回错误
+func (ctx *DefaultProperties) Set(key string, value interface{}) error {
+	path, err := parseSetPath(key)
+	if err != nil {
+		return err
+	}
+	v := normalizeValue(value)
+	return ctx.updateValue(func(root interface{}) (interface{}, bool, error) {
+		ret, err := setNode(root, path, v)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid key %s: %s", key, err.Error())
+		}
+		return ret, true, nil
+	}, func(m map[string]encryptedValue) map[string]encryptedValue {
+		return updateEncrypted(m, path, false)
+	})
+}
+
+// 删除属性，删除列表元素时之后的元素前移
+// return: key格式错误时返回错误，属性不存在时不做任何修改
+func (ctx *DefaultProperties) Delete(key string) error {
+	path, err := parseSetPath(key)
+	if err != nil {
+		return err
+	}
+	return ctx.updateValue(func(root interface{}) (interface{}, bool, error) {
+		ret, ok := deleteNode(root, path)
+		return ret, ok, nil
+	}, func(m map[string]encryptedValue) map[string]encryptedValue {
+		return updateEncrypted(m, path, true)
+	})
+}
+
+// 设置默认值，属性不存在时使用，之后每次ReadValue、LayeredProperties.Load后同样生效
+// 同一key重复设置时替换之前的默认值
+// return: key格式错误时返回错误
+func (ctx *DefaultProperties) SetDefault(key string, value interface{}) error {
+	path, err := parseSetPath(key)
+	if err != nil {
+		return err
+	}
+	d := defaultValue{key: key, path: path, value: normalizeValue(value)}
+	return ctx.updateValue(func(root interface{}) (interface{}, bool, error) {
+		replaced := false
+		for i := range ctx.defaults {
+			if ctx.defaults[i].key == key {
+				ctx.defaults[i] = d
+				replaced = true
+			}
+		}
+		if !replaced {
+			ctx.defaults = append(ctx.defaults, d)
+		}
+		ret, ok := applyDefault(root, d)
+		return ret, ok, nil
+	}, nil)
+}
+
+// 使用ValueLoader序列化当前属性值并写入w
+// ValueLoader实现了DocumentSerializer时使用ReadValue读取的原始内容，保留其中的注释及key的顺序
+// 解密的属性未修改时写入原来的密文
+func (ctx *DefaultProperties) WriteTo(w io.Writer) (int64, error) {
+	ctx.lock.RLock()
+	source := ctx.source
+	ctx.lock.RUnlock()
+
+	var v interface{} = Value{}
+	if cur := ctx.current(); cur.value != nil {
+		v = encryptBack(*cur.value, cur.encrypted)
+	}
+	var data string
+	var err error
+	if ds, ok := ctx.loader.(DocumentSerializer); ok && source != nil {
+		data, err = ds.SerializeDocument(source, v)
+	} else {
+		data, err = ctx.loader.Serialize(v)
+	}
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.WriteString(w, data)
+	return int64(n), err
+}
+
+// 将WriteTo的内容写入文件，先写入同目录下的临时文件再替换，文件已存在时保留其权限
+func (ctx *DefaultProperties) SaveFile(filename string) error {
+	mode := os.FileMode(0644)
+	if fi, err := os.Stat(filename); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	f, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+
+	_, err = ctx.WriteTo(f)
+	if err == nil {
+		err = f.Chmod(mode)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// 在当前属性值的副本上执行fn，fn返回true时替换属性值并通知订阅者
+// param: encrypted 不为nil时在替换属性值前更新解密过的属性
+func (ctx *DefaultProperties) updateValue(fn func(root interface{}) (interface{}, bool, error),
+	encrypted func(m map[string]encryptedValue) map[string]encryptedValue) error {
+	ctx.lock.Lock()
+	var root interface{} = Value{}
+	if cur := ctx.current().value; cur != nil {
+		root = copyValue(*cur)
+	}
+	ret, changed, err := fn(root)
+	if err != nil || !changed {
+		ctx.lock.Unlock()
+		return err
+	}
+	v, _ := ret.(map[string]interface{})
+	if v == nil {
+		v = Value{}
+	}
+	if err := ctx.checkRefs(&v); err != nil {
+		ctx.lock.Unlock()
+		return err
+	}
+	if encrypted != nil {
+		ctx.encrypted = encrypted(ctx.encrypted)
+	}
+	old := ctx.storeLocked(&v)
+	ctx.lock.Unlock()
+
+	ctx.notify(old, &v)
+	return nil
+}
+
+// 依次应用默认值
+// param: onSet 不为nil时在每个使用了默认值的key上调用
+func (ctx *DefaultProperties) applyDefaults(v *Value, onSet func(key string)) {
+	ctx.lock.RLock()
+	defaults := append([]defaultValue(nil), ctx.defaults...)
+	ctx.lock.RUnlock()
+
+	if *v == nil {
+		*v = Value{}
+	}
+	for _, d := range defaults {
+		if ret, ok := applyDefault(*v, d); ok {
+			*v = ret.(map[string]interface{})
+			if onSet != nil {
+				onSet(d.key)
+			}
+		}
+	}
+}
+
+// 属性不存在且父节点为map、列表或不存在时设置默认值，默认值会被复制
+// return: 设置后的根节点，是否设置
+func applyDefault(root interface{}, d defaultValue) (interface{}, bool) {
+	var node interface{} = root
+	for _, seg := range d.path {
+		next, ok := childOf(walkable(node), seg)
+		if !ok {
+			switch walkable(node).(type) {
+			case map[string]interface{}, []interface{}, nil:
+				ret, err := setNode(root, d.path, copyValue(d.value))
+				if err != nil {
+					return root, false
+				}
+				return ret, true
+			}
+			return root, false
+		}
+		node = next
+	}
+	return root, false
+}
+
+func parseSetPath(key string) ([]pathSegment, error) {
+	path, err := parsePath(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return nil, fmt.Errorf("invalid key: empty key")
+	}
+	return path, nil
+}
+
+// 修改node（需为副本）中path对应的节点
+// return: 修改后的node
+func setNode(node interface{}, path []pathSegment, v interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	seg := path[0]
+	node = walkable(node)
+	if seg.isIndex {
+		list, ok := node.([]interface{})
+		if !ok || seg.index > len(list) {
+			return nil, fmt.Errorf("index %d out of range", seg.index)
+		}
+		var child interface{}
+		if seg.index == len(list) {
+			list = append(list, nil)
+		} else {
+			child = list[seg.index]
+		}
+		c, err := setNode(child, path[1:], v)
+		if err != nil {
+			return nil, err
+		}
+		list[seg.index] = c
+		return list, nil
+	}
+
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		m = map[string]interface{}{}
+	}
+	c, err := setNode(m[seg.key], path[1:], v)
+	if err != nil {
+		return nil, err
+	}
+	m[seg.key] = c
+	return m, nil
+}
+
+// 删除node（需为副本）中path对应的节点
+// return: 修改后的node，节点是否存在
+func deleteNode(node interface{}, path []pathSegment) (interface{}, bool) {
+	seg := path[0]
+	node = walkable(node)
+	switch o := node.(type) {
+	case map[string]interface{}:
+		if seg.isIndex {
+			return node, false
+		}
+		child, ok := o[seg.key]
+		if !ok {
+			return node, false
+		}
+		if len(path) == 1 {
+			delete(o, seg.key)
+			return o, true
+		}
+		c, ok := deleteNode(child, path[1:])
+		o[seg.key] = c
+		return o, ok
+	case []interface{}:
+		if !seg.isIndex || seg.index >= len(o) {
+			return node, false
+		}
+		if len(path) == 1 {
+			return append(o[:seg.index], o[seg.index+1:]...), true
+		}
+		c, ok := deleteNode(o[seg.index], path[1:])
+		o[seg.index] = c
+		return o, ok
+	}
+	return node, false
+}
@@ -0,0 +1,138 @@
+package yfig
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var ErrImmutable = errors.New("properties is immutable")
+
+// 某一版本属性值的只读视图，用于在一次请求中读取一致的属性
+// ReadValue返回ErrImmutable，SetValueReader、SetValueLoader不生效
+type Snapshot struct {
+	state  *propState
+	loader ValueLoader
+	env    map[string]string
+	// 通过Sub创建时的属性前缀
+	prefix string
+}
+
+func (ctx *DefaultProperties) Snapshot() *Snapshot {
+	ctx.lock.RLock()
+	env := ctx.Env
+	ctx.lock.RUnlock()
+
+	return &Snapshot{
+		state:  ctx.current(),
+		loader: ctx.loader,
+		env:    env,
+	}
+}
+
+// return: 属性值的版本，每次重新加载时递增
+func (ctx *DefaultProperties) Version() uint64 {
+	return ctx.current().version
+}
+
+func (s *Snapshot) SetValueReader(r ValueReader) {}
+
+func (s *Snapshot) SetValueLoader(l ValueLoader) {}
+
+func (s *Snapshot) ReadValue(r io.Reader) error {
+	return ErrImmutable
+}
+
+func (s *Snapshot) Get(key string, defaultValue string) string {
+	return s.state.get(subKey(s.prefix, key), defaultValue)
+}
+
+func (s *Snapshot) GetValue(key string, result interface{}) error {
+	return s.state.getValue(subKey(s.prefix, key), result, s.loader)
+}
+
+// return: 同一版本中以prefix为根的快照
+func (s *Snapshot) Sub(prefix string) Properties {
+	ret := *s
+	ret.prefix = subKey(s.prefix, prefix)
+	return &ret
+}
+
+// 快照不会变化，不调用fn
+func (s *Snapshot) OnChange(prefix string, fn ChangeFunc) func() {
+	return func() {}
+}
+
+func (s *Snapshot) Snapshot() *Snapshot {
+	return s
+}
+
+// return: 快照的版本，与创建快照时DefaultProperties.Version一致
+func (s *Snapshot) Version() uint64 {
+	return s.state.version
+}
+
+// return: 属性值（Sub创建的快照为前缀下的属性值）内容的sha256（十六进制），内容相同的快照返回相同的值
+func (s *Snapshot) Hash() string {
+	if s.prefix == "" {
+		return s.state.contentHash()
+	}
+	node, _ := lookupPath(s.state.value, s.prefix)
+	return hashValue(node)
+}
+
+func (s *Snapshot) Has(key string) bool {
+	return s.state.has(subKey(s.prefix, key))
+}
+
+func (s *Snapshot) Keys(prefix string) []string {
+	return s.state.keys(s.prefix, prefix)
+}
+
+func (s *Snapshot) Children(prefix string) []string {
+	return s.state.children(s.prefix, prefix)
+}
+
+func (s *Snapshot) Walk(fn WalkFunc) error {
+	return s.state.walk(s.prefix, "", fn)
+}
+
+func (s *Snapshot) AllSettings() Value {
+	return s.state.settings(s.prefix)
+}
+
+func (s *Snapshot) getLoader() ValueLoader {
+	return s.loader
+}
+
+func (s *Snapshot) lookupEnv(name string) (string, bool) {
+	if v, ok := s.env[name]; ok {
+		return v, true
+	}
+	return os.LookupEnv(name)
+}
+
+func (s *propState) contentHash() string {
+	s.hashOnce.Do(func() {
+		var v interface{}
+		if s.value != nil {
+			v = *s.value
+		}
+		s.hash = hashValue(v)
+	})
+	return s.hash
+}
+
+func hashValue(v interface{}) string {
+	h := sha256.New()
+	if b, err := json.Marshal(v); err == nil {
+		h.Write(b)
+	} else {
+		fmt.Fprint(h, v)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
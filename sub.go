@@ -0,0 +1,102 @@
+package yfig
+
+import (
+	"io"
+	"strings"
+)
+
+// 以某一属性前缀为根的视图，读取时在key前拼接前缀，始终读取DefaultProperties的当前属性值
+// ReadValue返回ErrImmutable，SetValueReader、SetValueLoader不生效，使用原属性的ValueLoader
+type SubProperties struct {
+	parent *DefaultProperties
+	prefix string
+}
+
+// param: prefix 属性前缀，格式见parsePath，如"DataSources.default"
+// return: 以prefix为根的属性视图，重新加载后读取新的属性值
+func (ctx *DefaultProperties) Sub(prefix string) Properties {
+	return &SubProperties{parent: ctx, prefix: prefix}
+}
+
+// return: 视图的属性前缀
+func (s *SubProperties) Prefix() string {
+	return s.prefix
+}
+
+func (s *SubProperties) SetValueReader(r ValueReader) {}
+
+func (s *SubProperties) SetValueLoader(l ValueLoader) {}
+
+func (s *SubProperties) ReadValue(r io.Reader) error {
+	return ErrImmutable
+}
+
+func (s *SubProperties) Get(key string, defaultValue string) string {
+	return s.parent.Get(subKey(s.prefix, key), defaultValue)
+}
+
+func (s *SubProperties) GetValue(key string, result interface{}) error {
+	return s.parent.GetValue(subKey(s.prefix, key), result)
+}
+
+func (s *SubProperties) Snapshot() *Snapshot {
+	ret := s.parent.Snapshot()
+	ret.prefix = s.prefix
+	return ret
+}
+
+func (s *SubProperties) Sub(prefix string) Properties {
+	return &SubProperties{parent: s.parent, prefix: subKey(s.prefix, prefix)}
+}
+
+// 订阅视图内prefix下的属性变更，ChangeEvent.Key为相对于视图的属性名
+func (s *SubProperties) OnChange(prefix string, fn ChangeFunc) func() {
+	return s.parent.OnChange(subKey(s.prefix, prefix), func(e ChangeEvent) {
+		e.Key = trimKeyPrefix(e.Key, s.prefix)
+		fn(e)
+	})
+}
+
+func (s *SubProperties) Has(key string) bool {
+	return s.parent.Has(subKey(s.prefix, key))
+}
+
+func (s *SubProperties) Keys(prefix string) []string {
+	return s.parent.current().keys(s.prefix, prefix)
+}
+
+func (s *SubProperties) Children(prefix string) []string {
+	return s.parent.current().children(s.prefix, prefix)
+}
+
+func (s *SubProperties) Walk(fn WalkFunc) error {
+	return s.parent.current().walk(s.prefix, "", fn)
+}
+
+// return: 前缀下属性值的副本，前缀不存在或不是map时返回空的Value
+func (s *SubProperties) AllSettings() Value {
+	return s.parent.current().settings(s.prefix)
+}
+
+func (s *SubProperties) getLoader() ValueLoader {
+	return s.parent.getLoader()
+}
+
+func (s *SubProperties) lookupEnv(name string) (string, bool) {
+	return s.parent.lookupEnv(name)
+}
+
+// 视图中的key对应的完整属性名，key为空时为prefix本身
+func subKey(prefix, key string) string {
+	if key == "" {
+		return prefix
+	}
+	return joinKey(prefix, key)
+}
+
+func trimKeyPrefix(key, prefix string) string {
+	if key == prefix {
+		return ""
+	}
+	return strings.TrimPrefix(key, prefix+".")
+}
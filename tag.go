@@ -0,0 +1,79 @@
+package yfig
+
+import "strings"
+
+// 字段被标记为secret时，日志、错误信息中使用的替代值
+const Redacted = "******"
+
+type tagOptions struct {
+	name         string
+	defaultValue string
+	hasDefault   bool
+	// 属性不存在（且没有env、default）时返回错误
+	required bool
+	// 属性不存在时使用的环境变量
+	env string
+	// 字符串属性填充到slice时的分隔符
+	sep string
+	// 字符串属性填充到time.Time时的格式
+	layout string
+	// 日志、错误信息中不输出属性值
+	secret bool
+}
+
+// 解析fig tag，格式为 name[,option...]，选项内容中的逗号使用"\,"：
+// default=value: 属性不存在时的默认值
+// required: 属性不存在时返回错误
+// env=VAR: 属性不存在时使用环境变量VAR的值，优先于default
+// sep=;: 字符串属性按分隔符拆分后填充到slice
+// layout=2006-01-02: 字符串属性按格式解析后填充到time.Time
+// secret: 属性值为敏感信息
+func parseTag(tag string) tagOptions {
+	tags := splitTagOptions(tag)
+	ret := tagOptions{name: strings.TrimSpace(tags[0])}
+	for _, o := range tags[1:] {
+		name, value := o, ""
+		if i := strings.Index(o, "="); i != -1 {
+			name, value = o[:i], o[i+1:]
+		}
+		switch strings.TrimSpace(name) {
+		case "default":
+			ret.defaultValue = value
+			ret.hasDefault = true
+		case "required":
+			ret.required = true
+		case "env":
+			ret.env = strings.TrimSpace(value)
+		case "sep":
+			ret.sep = value
+		case "layout":
+			ret.layout = value
+		case "secret":
+			ret.secret = true
+		}
+	}
+	return ret
+}
+
+// 按","分割tag选项，"\,"表示选项内容中的逗号
+func splitTagOptions(tag string) []string {
+	if !strings.Contains(tag, `\,`) {
+		return strings.Split(tag, ",")
+	}
+	var ret []string
+	buf := strings.Builder{}
+	for i := 0; i < len(tag); i++ {
+		if tag[i] == '\\' && i+1 < len(tag) && tag[i+1] == ',' {
+			buf.WriteByte(',')
+			i++
+			continue
+		}
+		if tag[i] == ',' {
+			ret = append(ret, buf.String())
+			buf.Reset()
+			continue
+		}
+		buf.WriteByte(tag[i])
+	}
+	return append(ret, buf.String())
+}
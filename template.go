@@ -0,0 +1,186 @@
+package yfig
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/ghodss/yaml"
+)
+
+// 添加模板函数，同名时替换内置函数
+// 函数需返回1个值，或2个值且第二个为error，否则New失败
+func WithTemplateFuncs(funcs template.FuncMap) Opt {
+	return func(ctx *DefaultProperties) error {
+		for k, v := range funcs {
+			if err := checkTemplateFunc(k, v); err != nil {
+				return err
+			}
+		}
+		if ctx.funcs == nil {
+			ctx.funcs = template.FuncMap{}
+		}
+		for k, v := range funcs {
+			ctx.funcs[k] = v
+		}
+		return nil
+	}
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// 与text/template.Funcs的检查相同，避免执行模板时panic
+func checkTemplateFunc(name string, fn interface{}) error {
+	if name == "" {
+		return errors.New("template func name is empty")
+	}
+	for i, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return fmt.Errorf("invalid template func name %q", name)
+		}
+	}
+	t := reflect.TypeOf(fn)
+	if t == nil || t.Kind() != reflect.Func {
+		return fmt.Errorf("template func %s is not a function", name)
+	}
+	switch {
+	case t.NumOut() == 1:
+	case t.NumOut() == 2 && t.Out(1) == errorType:
+	default:
+		return fmt.Errorf("template func %s must return 1 value, or 2 values with the second of type error", name)
+	}
+	return nil
+}
+
+// ExecTemplate使用的函数，参数顺序便于在管道中使用，如{{ env "HOST" "" | default "localhost" | lower }}
+func (ctx *DefaultProperties) templateFuncs() template.FuncMap {
+	ret := template.FuncMap{
+		"env":       ctx.getEnvValue,
+		"secret":    ctx.secrets.templateFunc,
+		"default":   defaultFunc,
+		"required":  requiredFunc,
+		"file":      fileFunc,
+		"base64enc": base64Enc,
+		"base64dec": base64Dec,
+		"lower":     strings.ToLower,
+		"upper":     strings.ToUpper,
+		"trim":      strings.TrimSpace,
+		"split":     splitFunc,
+		"join":      joinFunc,
+		"toJson":    toJson,
+		"toYaml":    toYaml,
+		"hostname":  os.Hostname,
+		"now":       time.Now,
+		"add":       arithmetic(func(a, b int64) (int64, error) { return a + b, nil }),
+		"sub":       arithmetic(func(a, b int64) (int64, error) { return a - b, nil }),
+		"mul":       arithmetic(func(a, b int64) (int64, error) { return a * b, nil }),
+		"div": arithmetic(func(a, b int64) (int64, error) {
+			if b == 0 {
+				return 0, errors.New("division by zero")
+			}
+			return a / b, nil
+		}),
+		"mod": arithmetic(func(a, b int64) (int64, error) {
+			if b == 0 {
+				return 0, errors.New("division by zero")
+			}
+			return a % b, nil
+		}),
+	}
+	for k, v := range ctx.funcs {
+		ret[k] = v
+	}
+	return ret
+}
+
+// {{ value | default "x" }}: value为空（nil、零值、空字符串、空集合）时返回def
+func defaultFunc(def interface{}, value ...interface{}) interface{} {
+	if len(value) == 0 || isEmptyTemplateValue(value[0]) {
+		return def
+	}
+	return value[0]
+}
+
+// {{ value | required "msg" }}: value为空时返回错误msg
+func requiredFunc(msg string, value interface{}) (interface{}, error) {
+	if isEmptyTemplateValue(value) {
+		return nil, errors.New(msg)
+	}
+	return value, nil
+}
+
+func isEmptyTemplateValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	return isEmptyValue(reflect.ValueOf(v))
+}
+
+// {{ file "path" }}: 文件内容
+func fileFunc(name string) (string, error) {
+	b, err := os.ReadFile(name)
+	return string(b), err
+}
+
+func base64Enc(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func base64Dec(s string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	return string(b), err
+}
+
+// {{ "a,b" | split "," }}
+func splitFunc(sep, s string) []string {
+	return strings.Split(s, sep)
+}
+
+// {{ list | join "," }}: list为slice或array，元素使用fmt.Sprint
+func joinFunc(sep string, list interface{}) (string, error) {
+	if s, ok := list.([]string); ok {
+		return strings.Join(s, sep), nil
+	}
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: cannot join %T", list)
+	}
+	s := make([]string, v.Len())
+	for i := range s {
+		s[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return strings.Join(s, sep), nil
+}
+
+func toJson(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// 去掉末尾的换行，多行内容需要自行处理缩进
+func toYaml(v interface{}) (string, error) {
+	b, err := yaml.Marshal(v)
+	return strings.TrimSuffix(string(b), "\n"), err
+}
+
+// 参数为数字或数字字符串，按int64计算
+func arithmetic(op func(a, b int64) (int64, error)) func(a, b interface{}) (int64, error) {
+	return func(a, b interface{}) (int64, error) {
+		x, err := toInt64(a, 64)
+		if err != nil {
+			return 0, err
+		}
+		y, err := toInt64(b, 64)
+		if err != nil {
+			return 0, err
+		}
+		return op(x, y)
+	}
+}
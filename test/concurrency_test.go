@@ -0,0 +1,104 @@
+package test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ydx1011/yfig"
+)
+
+// 使用go test -race运行
+func TestConcurrentGetDuringReload(t *testing.T) {
+	config := yfig.New()
+	if err := config.ReadValue(strings.NewReader("ServerPort: 0\nName: n0\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	wg := sync.WaitGroup{}
+	errs := make(chan error, 8)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				name := config.Get("Name", "")
+				port := 0
+				if err := config.GetValue("ServerPort", &port); err != nil {
+					errs <- err
+					return
+				}
+				if !strings.HasPrefix(name, "n") {
+					errs <- fmt.Errorf("unexpected name %s", name)
+					return
+				}
+				test := struct {
+					Port int    `fig:"ServerPort"`
+					Name string `fig:"Name"`
+				}{}
+				if err := yfig.Fill(config, &test); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 1; i <= 200; i++ {
+		err := config.ReadValue(strings.NewReader(fmt.Sprintf("ServerPort: %d\nName: n%d\n", i, i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+	if v := config.Get("ServerPort", ""); v != "200" {
+		t.Fatalf("expect 200 but get %s", v)
+	}
+}
+
+func TestConcurrentLayeredReload(t *testing.T) {
+	config, err := yfig.LoadLayered(yfig.NewMapSource("base", yfig.Value{"ServerPort": 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if v := config.Get("ServerPort", ""); v == "" {
+				t.Error("ServerPort not found")
+				return
+			}
+			config.Origin("ServerPort")
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		config.AddSource(yfig.NewMapSource(fmt.Sprintf("s%d", i), yfig.Value{"ServerPort": i}))
+		if err := config.Load(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
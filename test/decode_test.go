@@ -0,0 +1,184 @@
+package test
+
+import (
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ydx1011/yfig"
+)
+
+type decodeStruct struct {
+	Timeout  time.Duration            `fig:"timeout"`
+	Retries  []time.Duration          `fig:"retries"`
+	MaxBody  yfig.ByteSize            `fig:"maxBody"`
+	StartAt  time.Time                `fig:"startAt"`
+	Endpoint *url.URL                 `fig:"endpoint"`
+	IP       net.IP                   `fig:"ip"`
+	Pattern  *regexp.Regexp           `fig:"pattern"`
+	Level    level                    `fig:"level"`
+	Limits   map[string]yfig.ByteSize `fig:"limits"`
+	Backup   yfig.ByteSize            `fig:"backup,default=1KB"`
+}
+
+type level int
+
+func (l *level) UnmarshalText(text []byte) error {
+	*l = level(len(text))
+	return nil
+}
+
+func TestDecodeHooks(t *testing.T) {
+	config := yfig.New()
+	err := config.ReadValue(strings.NewReader(`
+timeout: 30s
+retries: [1s, 2s]
+maxBody: 10MiB
+startAt: 2026-01-01T00:00:00Z
+endpoint: https://x/api
+ip: 10.0.0.1
+pattern: ^a+$
+level: debug
+limits:
+  upload: 1.5K
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	test := decodeStruct{}
+	if err := yfig.Fill(config, &test); err != nil {
+		t.Fatal(err)
+	}
+	if test.Timeout != 30*time.Second || !reflect.DeepEqual(test.Retries, []time.Duration{time.Second, 2 * time.Second}) {
+		t.Fatalf("duration not decoded: %v %v", test.Timeout, test.Retries)
+	}
+	if test.MaxBody != 10*yfig.MiB || test.MaxBody.String() != "10MiB" {
+		t.Fatalf("byte size not decoded: %d", test.MaxBody)
+	}
+	if !test.StartAt.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("time not decoded: %v", test.StartAt)
+	}
+	if test.Endpoint == nil || test.Endpoint.Host != "x" || test.Endpoint.Path != "/api" {
+		t.Fatalf("url not decoded: %v", test.Endpoint)
+	}
+	if !test.IP.Equal(net.ParseIP("10.0.0.1")) {
+		t.Fatalf("ip not decoded: %v", test.IP)
+	}
+	if test.Pattern == nil || !test.Pattern.MatchString("aaa") {
+		t.Fatalf("regexp not decoded: %v", test.Pattern)
+	}
+	if test.Level != 5 {
+		t.Fatalf("TextUnmarshaler not used: %d", test.Level)
+	}
+	if test.Limits["upload"] != 1536 || test.Backup != 1000 {
+		t.Fatalf("byte size not decoded: %v %d", test.Limits, test.Backup)
+	}
+
+	var d time.Duration
+	if err := config.GetValue("timeout", &d); err != nil || d != 30*time.Second {
+		t.Fatalf("GetValue expect 30s but get %v, %v", d, err)
+	}
+}
+
+func TestRegisterDecodeHook(t *testing.T) {
+	type upper string
+	yfig.RegisterDecodeHook(reflect.TypeOf(upper("")), func(raw interface{}) (interface{}, error) {
+		s, _ := raw.(string)
+		return upper(strings.ToUpper(s)), nil
+	})
+	defer yfig.RegisterDecodeHook(reflect.TypeOf(upper("")), nil)
+
+	config := yfig.New()
+	if err := config.ReadValue(strings.NewReader(`name: abc`)); err != nil {
+		t.Fatal(err)
+	}
+	var v upper
+	if err := config.GetValue("name", &v); err != nil || v != "ABC" {
+		t.Fatalf("expect ABC but get %s, %v", v, err)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	cases := map[string]yfig.ByteSize{
+		"1024":  1024,
+		"10KB":  10000,
+		"10kib": 10240,
+		"2G":    2 * yfig.GiB,
+		"1.5MB": 1500000,
+	}
+	for s, expect := range cases {
+		v, err := yfig.ParseByteSize(s)
+		if err != nil || v != expect {
+			t.Fatalf("%s expect %d but get %d, %v", s, expect, v, err)
+		}
+	}
+	if _, err := yfig.ParseByteSize("10XB"); err == nil {
+		t.Fatal("expect error")
+	}
+}
+
+type recursiveList []recursiveList
+
+func TestDecodeRecursiveType(t *testing.T) {
+	config := yfig.New()
+	if err := config.ReadValue(strings.NewReader("List: [[], [[]]]\n")); err != nil {
+		t.Fatal(err)
+	}
+	test := struct {
+		List recursiveList `fig:"List"`
+	}{}
+	if err := yfig.Fill(config, &test); err != nil {
+		t.Fatal(err)
+	}
+	if len(test.List) != 2 || len(test.List[1]) != 1 {
+		t.Fatalf("unexpected %v", test.List)
+	}
+}
+
+type untaggedServer struct {
+	Name    string
+	Timeout time.Duration
+	Size    yfig.ByteSize `json:"max_size"`
+	Next    *untaggedServer
+}
+
+func TestDecodeUntaggedStruct(t *testing.T) {
+	config := yfig.New()
+	err := config.ReadValue(strings.NewReader(`
+x:
+  timeout: 30s
+  size: 10KB
+server:
+  Name: a
+  Timeout: 1s
+  max_size: 1MiB
+  Next:
+    Name: b
+    Timeout: 2m
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := struct {
+		Timeout time.Duration
+		Size    yfig.ByteSize
+	}{}
+	if err := config.GetValue("x", &x); err != nil || x.Timeout != 30*time.Second || x.Size != 10000 {
+		t.Fatalf("unexpected %+v, %v", x, err)
+	}
+
+	test := struct {
+		Server untaggedServer `fig:"server"`
+	}{}
+	if err := yfig.Fill(config, &test); err != nil {
+		t.Fatal(err)
+	}
+	s := test.Server
+	if s.Name != "a" || s.Timeout != time.Second || s.Size != yfig.MiB || s.Next == nil || s.Next.Name != "b" || s.Next.Timeout != 2*time.Minute {
+		t.Fatalf("unexpected %+v", s)
+	}
+}
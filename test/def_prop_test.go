@@ -3,11 +3,12 @@ package test
 import (
 	"fmt"
 	"testing"
-	"yfig"
+
+	"github.com/ydx1011/yfig"
 )
 
 func TestYml(t *testing.T) {
-	file, err := yfig.LoadYamlFile("E:\\git_code\\yfig\\test\\test.yaml")
+	file, err := yfig.LoadYamlFile("test.yaml")
 	if err != nil {
 		fmt.Println(err)
 	}
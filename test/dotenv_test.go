@@ -0,0 +1,72 @@
+package test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ydx1011/yfig"
+)
+
+func TestParseDotenv(t *testing.T) {
+	vars, err := yfig.ReadDotenvFile("test.env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expects := map[string]string{
+		"DB_USER": "root",
+		"DB_PASS": "p@ss#word",
+		"DB_HOST": "localhost",
+		"DB_DSN":  "root:p@ss#word@tcp(localhost)/db\n",
+		"MULTI":   "line1\nline2",
+		"PORT":    "3306",
+	}
+	for k, expect := range expects {
+		if vars[k] != expect {
+			t.Fatalf("key %s expect %q but get %q", k, expect, vars[k])
+		}
+	}
+
+	if _, err := yfig.ParseDotenv(strings.NewReader("A=\"unterminated")); err == nil {
+		t.Fatal("expect unterminated error")
+	}
+}
+
+func TestDotenvTemplate(t *testing.T) {
+	os.Setenv("DB_USER", "admin")
+	defer os.Unsetenv("DB_USER")
+
+	config := yfig.New(yfig.WithDotenv("test.env", "not_exist.env"))
+	err := config.ReadValue(strings.NewReader(`
+DataSources:
+  default:
+    User: "{{ env "DB_USER" }}"
+    Password: "{{ env "DB_PASS" }}"
+    Host: "{{.Env.DB_HOST}}"
+    Dsn: "{{ env "DB_DSN" | trim }}"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := config.Get("DataSources.default.User", ""); v != "admin" {
+		t.Fatalf("expect process env first but get %s", v)
+	}
+	if v := config.Get("DataSources.default.Password", ""); v != "p@ss#word" {
+		t.Fatalf("expect p@ss#word but get %s", v)
+	}
+	if v := config.Get("DataSources.default.Host", ""); v != "localhost" {
+		t.Fatalf("expect localhost but get %s", v)
+	}
+	// 文件中的引用与env函数相同，进程环境变量优先
+	if v := config.Get("DataSources.default.Dsn", ""); v != "admin:p@ss#word@tcp(localhost)/db" {
+		t.Fatalf("expect process env first in reference but get %s", v)
+	}
+
+	layered, err := yfig.LoadLayered(yfig.NewFileSource("test.env", yfig.NewDotenvReader()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := yfig.GetInt(layered)("PORT", 0); v != 3306 {
+		t.Fatalf("expect 3306 but get %d", v)
+	}
+}
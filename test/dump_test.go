@@ -0,0 +1,80 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ydx1011/yfig"
+)
+
+const dumpYaml = `
+ServerPort: 8080
+DataSources:
+  default:
+    DriverName: mysql
+    Password: p1
+    Dsn: "user:p2@tcp(db)/app"
+Redis:
+  AuthToken: t1
+Users:
+  - Name: a
+    Pin: "1234"
+`
+
+type dumpUser struct {
+	Name string `fig:"Name"`
+	Pin  string `fig:"Pin,secret"`
+}
+
+type dumpConfig struct {
+	Users []dumpUser `fig:"Users"`
+	x     string     `figPx:"DataSources.default"`
+	Dsn   string     `fig:"Dsn,secret"`
+}
+
+func TestDump(t *testing.T) {
+	config := yfig.New()
+	if err := config.ReadValue(strings.NewReader(dumpYaml)); err != nil {
+		t.Fatal(err)
+	}
+
+	flat, err := config.Dump(yfig.DumpFlat, yfig.RedactFields(&dumpConfig{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `DataSources.default.DriverName=mysql
+DataSources.default.Dsn=******
+DataSources.default.Password=******
+Redis.AuthToken=******
+ServerPort=8080
+Users[0].Name=a
+Users[0].Pin=******
+`
+	if flat != expect {
+		t.Fatalf("unexpected dump:\n%s", flat)
+	}
+
+	for _, format := range []string{"yaml", "json", ""} {
+		out, err := config.Dump(format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(out, "p1") || strings.Contains(out, "t1") || !strings.Contains(out, "p2") {
+			t.Fatalf("unexpected %s dump:\n%s", format, out)
+		}
+	}
+	if _, err := config.Dump("none"); err == nil {
+		t.Fatal("expect unknown format error")
+	}
+
+	out, err := yfig.Dump(config.Sub("DataSources"), yfig.DumpFlat, yfig.RedactPatterns("*.dsn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "default.DriverName=mysql\ndefault.Dsn=******\ndefault.Password=p1\n" {
+		t.Fatalf("unexpected dump:\n%s", out)
+	}
+	if v := config.Get("DataSources.default.Password", ""); v != "p1" {
+		t.Fatalf("dump should not modify properties, get %s", v)
+	}
+}
@@ -0,0 +1,136 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ydx1011/yfig"
+)
+
+func TestEncrypt(t *testing.T) {
+	oldKey, _ := yfig.GenerateKey()
+	newKey, _ := yfig.GenerateKey()
+	old, err := yfig.ParseKeyring("old:" + oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := old.Encrypt("p@ss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !yfig.IsEncrypted(enc) || strings.Contains(enc, "p@ss") {
+		t.Fatalf("unexpected encrypted value %s", enc)
+	}
+
+	// 轮换：新密钥在前，旧密钥仍可解密
+	rotated, err := yfig.ParseKeyring("new:" + newKey + "\nold:" + oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.Primary() != "new" {
+		t.Fatalf("expect primary new but get %s", rotated.Primary())
+	}
+	yaml := "Password: " + enc + "\nName: demo\n"
+	config := yfig.New(yfig.WithKeyring(rotated))
+	if err := config.ReadValue(strings.NewReader(yaml)); err != nil {
+		t.Fatal(err)
+	}
+	if v := config.Get("Password", ""); v != "p@ss" {
+		t.Fatalf("expect p@ss but get %s", v)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	config.WriteTo(buf)
+	if !strings.Contains(buf.String(), enc) {
+		t.Fatalf("unchanged value should be written encrypted:\n%s", buf.String())
+	}
+
+	text, err := rotated.Rotate(yaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(text, enc) || !strings.Contains(text, "kid:new") || !strings.HasSuffix(text, "Name: demo\n") {
+		t.Fatalf("unexpected rotated text:\n%s", text)
+	}
+	newOnly, _ := yfig.ParseKeyring(newKey)
+	if _, err := newOnly.Decrypt(enc); !errors.Is(err, yfig.ErrNoKey) {
+		t.Fatalf("expect ErrNoKey but get %v", err)
+	}
+	config = yfig.New(yfig.WithKeyring(newOnly))
+	if err := config.ReadValue(strings.NewReader(yaml)); err == nil {
+		t.Fatal("expect decrypt error")
+	}
+
+	t.Setenv(yfig.KeyringEnv, "new:"+newKey)
+	config = yfig.New()
+	if err := config.ReadValue(strings.NewReader(text)); err != nil {
+		t.Fatal(err)
+	}
+	if v := config.Get("Password", ""); v != "p@ss" {
+		t.Fatalf("expect p@ss but get %s", v)
+	}
+}
+
+func TestEncryptDump(t *testing.T) {
+	key, _ := yfig.GenerateKey()
+	k, _ := yfig.ParseKeyring(key)
+	enc, _ := k.Encrypt("user:pw@db")
+	config := yfig.New(yfig.WithKeyring(k))
+	if err := config.ReadValue(strings.NewReader("Db:\n  Dsn: " + enc + "\n  Hosts: [a, \"" + enc + "\"]\nName: demo\n")); err != nil {
+		t.Fatal(err)
+	}
+	s, err := config.Dump(yfig.DumpFlat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(s, "pw@db") || !strings.Contains(s, "Db.Dsn="+yfig.Redacted) || !strings.Contains(s, "Db.Hosts[0]=a") {
+		t.Fatalf("encrypted values should be redacted:\n%s", s)
+	}
+	s, err = yfig.Dump(config.Sub("Db"), yfig.DumpFlat, yfig.RedactPatterns())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(s, "pw@db") || !strings.Contains(s, "Dsn="+yfig.Redacted) {
+		t.Fatalf("encrypted values should be redacted:\n%s", s)
+	}
+}
+
+func TestEncryptReload(t *testing.T) {
+	key, _ := yfig.GenerateKey()
+	k, _ := yfig.ParseKeyring(key)
+	enc, _ := k.Encrypt("p")
+	config := yfig.New(yfig.WithKeyring(k))
+	write := func() string {
+		buf := bytes.NewBuffer(nil)
+		if _, err := config.WriteTo(buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	if err := config.ReadValue(strings.NewReader("Password: " + enc + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.ReadValue(strings.NewReader("Password: p\n")); err != nil {
+		t.Fatal(err)
+	}
+	if s := write(); strings.Contains(s, enc) {
+		t.Fatalf("plaintext value reloaded, should not be written encrypted:\n%s", s)
+	}
+
+	if err := config.ReadValue(strings.NewReader("Password: " + enc + "\nList: [\"" + enc + "\", \"" + enc + "\"]\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Set("Password", "p"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Delete("List[0]"); err != nil {
+		t.Fatal(err)
+	}
+	// Password已修改写入明文，List[1]前移后仍写入密文
+	if s := write(); strings.Count(s, enc) != 1 || !strings.Contains(s, "Password: p") {
+		t.Fatalf("unexpected written value:\n%s", s)
+	}
+}
@@ -0,0 +1,52 @@
+package test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ydx1011/yfig"
+)
+
+func TestEnvSource(t *testing.T) {
+	os.Setenv("YFIG_TEST_DATASOURCES_DEFAULT_DRIVERNAME", "postgres")
+	os.Setenv("YFIG_TEST_DATASOURCES_DEFAULT_MAX_IDLE", "20")
+	os.Setenv("YFIG_TEST_NEWKEY", "x")
+	defer os.Unsetenv("YFIG_TEST_DATASOURCES_DEFAULT_DRIVERNAME")
+	defer os.Unsetenv("YFIG_TEST_DATASOURCES_DEFAULT_MAX_IDLE")
+	defer os.Unsetenv("YFIG_TEST_NEWKEY")
+
+	config := yfig.New(yfig.WithOverlay(yfig.NewEnvSource("YFIG_TEST")))
+	err := config.ReadValue(strings.NewReader(`
+DataSources:
+  default:
+    DriverName: mysql
+    MAX_IDLE: 10
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := config.Get("DataSources.default.DriverName", ""); v != "postgres" {
+		t.Fatalf("expect postgres but get %s", v)
+	}
+	port := 0
+	if err := config.GetValue("DataSources.default.MAX_IDLE", &port); err != nil || port != 20 {
+		t.Fatalf("expect 20 but get %d, err: %v", port, err)
+	}
+	if v := config.Get("newkey", ""); v != "" {
+		t.Fatalf("expect no new key but get %s", v)
+	}
+
+	env := yfig.NewEnvSource("YFIG_TEST")
+	env.AllowNew = true
+	layered, err := yfig.LoadLayered(yfig.NewMapSource("base", yfig.Value{"ServerPort": 8080}), env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := layered.Get("newkey", ""); v != "x" {
+		t.Fatalf("expect x but get %s", v)
+	}
+	if name, _ := layered.Origin("newkey"); name != "env:YFIG_TEST" {
+		t.Fatalf("unexpected origin %s", name)
+	}
+}
@@ -0,0 +1,226 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ydx1011/yfig"
+)
+
+type fillServer struct {
+	Host string `fig:"host"`
+	Port int    `fig:"port" validate:"max=65535"`
+}
+
+type fillBase struct {
+	Name string `fig:"name"`
+}
+
+type fillDB struct {
+	Driver string `fig:"driver"`
+	Pool   struct {
+		Max int `fig:"max"`
+	} `fig:"pool"`
+}
+
+type fillConfig struct {
+	fillBase
+	DB      fillDB                 `fig:"db"`
+	Cache   *fillDB                `fig:"cache"`
+	Missing *fillDB                `fig:"missing"`
+	Servers []fillServer           `fig:"servers"`
+	Backups []*fillServer          `fig:"backups"`
+	Pair    [2]fillServer          `fig:"servers"`
+	Named   map[string]fillServer  `fig:"named"`
+	Extra   map[string]interface{} `fig:"extra"`
+	x       string                 `figPx:"app"`
+	Version string                 `fig:"version"`
+}
+
+const fillYaml = `
+name: demo
+db:
+  driver: mysql
+  pool:
+    max: 10
+cache:
+  driver: redis
+servers:
+  - host: a
+    port: 1
+  - host: b
+    port: 2
+backups:
+  - host: c
+    port: 3
+named:
+  main:
+    host: m
+    port: 8080
+extra:
+  k: v
+app:
+  version: 1.0.1
+`
+
+func TestFillNested(t *testing.T) {
+	config := yfig.New()
+	if err := config.ReadValue(strings.NewReader(fillYaml)); err != nil {
+		t.Fatal(err)
+	}
+	test := fillConfig{}
+	if err := yfig.Fill(config, &test); err != nil {
+		t.Fatal(err)
+	}
+	if test.Name != "demo" {
+		t.Fatalf("expect demo but get %s", test.Name)
+	}
+	if test.DB.Driver != "mysql" || test.DB.Pool.Max != 10 {
+		t.Fatalf("db not filled: %+v", test.DB)
+	}
+	if test.Cache == nil || test.Cache.Driver != "redis" {
+		t.Fatalf("cache not filled: %+v", test.Cache)
+	}
+	if test.Missing != nil {
+		t.Fatalf("expect nil but get %+v", test.Missing)
+	}
+	if len(test.Servers) != 2 || test.Servers[1].Host != "b" || test.Servers[1].Port != 2 {
+		t.Fatalf("servers not filled: %+v", test.Servers)
+	}
+	if len(test.Backups) != 1 || test.Backups[0].Host != "c" {
+		t.Fatalf("backups not filled: %+v", test.Backups)
+	}
+	if test.Pair[0].Host != "a" || test.Pair[1].Port != 2 {
+		t.Fatalf("pair not filled: %+v", test.Pair)
+	}
+	if test.Named["main"].Port != 8080 {
+		t.Fatalf("named not filled: %+v", test.Named)
+	}
+	if test.Extra["k"] != "v" {
+		t.Fatalf("extra not filled: %+v", test.Extra)
+	}
+	if test.Version != "1.0.1" {
+		t.Fatalf("expect 1.0.1 but get %s", test.Version)
+	}
+}
+
+func TestFillNestedValidate(t *testing.T) {
+	config := yfig.New()
+	err := config.ReadValue(strings.NewReader(`
+servers:
+  - host: a
+    port: 1
+  - host: b
+    port: 70000
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	test := struct {
+		Servers []fillServer `fig:"servers"`
+	}{}
+	err = yfig.Fill(config, &test)
+	var errs yfig.Errors
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("expect 1 error but get %v", err)
+	}
+	fe, ok := errs[0].(*yfig.FieldError)
+	if !ok {
+		t.Fatalf("expect FieldError but get %T", errs[0])
+	}
+	if fe.Field != "Servers[1].Port" || fe.Key != "servers[1].port" {
+		t.Fatalf("unexpected field %s key %s", fe.Field, fe.Key)
+	}
+}
+
+func TestFillNestedWithTagNames(t *testing.T) {
+	config := yfig.New()
+	err := config.ReadValue(strings.NewReader(`
+db:
+  driver: mysql
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	test := struct {
+		DB struct {
+			Driver string `yaml:"driver"`
+			Pool   int    `yaml:"pool,default=5"`
+		} `yaml:"db"`
+	}{}
+	err = yfig.FillExWithTagNames(config, &test, false, []string{"yamlPx"}, []string{"yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if test.DB.Driver != "mysql" || test.DB.Pool != 5 {
+		t.Fatalf("db not filled: %+v", test.DB)
+	}
+}
+
+type strictStruct struct {
+	x       string  `figPx:"app"`
+	Name    string  `fig:"name"`
+	Port    int     `fig:"port"`
+	Timeout int     `fig:"timeout"`
+	DB      fillDB  `fig:"db"`
+	Cache   *fillDB `fig:"cache"`
+}
+
+func TestFillStrict(t *testing.T) {
+	config := yfig.New()
+	err := config.ReadValue(strings.NewReader(`
+app:
+  name: demo
+  port: abc
+  nmae: typo
+  db:
+    driver: mysql
+    pool:
+      max: 1
+      min: 1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	test := strictStruct{}
+	if err := yfig.Fill(config, &test); err != nil {
+		t.Fatalf("expect nil in non-strict mode but get %v", err)
+	}
+
+	err = yfig.FillStrict(config, &test, true)
+	var errs yfig.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expect Errors but get %v", err)
+	}
+	found := map[string]error{}
+	for _, e := range errs {
+		ke, ok := e.(*yfig.KeyError)
+		if !ok {
+			t.Fatalf("expect KeyError but get %T", e)
+		}
+		found[ke.Key] = ke
+	}
+	expects := map[string]error{
+		"app.port":        yfig.ErrTypeMismatch,
+		"app.timeout":     yfig.ErrKeyNotFound,
+		"app.nmae":        yfig.ErrUnknownKey,
+		"app.db.pool.min": yfig.ErrUnknownKey,
+	}
+	if len(found) != len(expects) {
+		t.Fatalf("expect %d errors but get %v", len(expects), err)
+	}
+	for k, kind := range expects {
+		if !errors.Is(found[k], kind) {
+			t.Fatalf("key %s expect %v but get %v", k, kind, found[k])
+		}
+	}
+	if test.Name != "demo" || test.DB.Pool.Max != 1 {
+		t.Fatalf("not filled: %+v", test)
+	}
+
+	err = yfig.FillStrict(config, &test, false)
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("expect 2 errors but get %v", err)
+	}
+}
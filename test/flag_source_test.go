@@ -0,0 +1,110 @@
+package test
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/ydx1011/yfig"
+)
+
+type flagStruct struct {
+	Port        int    `fig:"ServerPort,default=8080" usage:"server port"`
+	LogResponse bool   `fig:"LogResponse"`
+	x           string `figPx:"DataSources.default"`
+	DriverName  string `fig:"DriverName,default=mysql"`
+}
+
+func TestFlagSource(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	source := yfig.NewFlagSource(fs)
+	if err := yfig.BindFlags(fs, &flagStruct{}); err != nil {
+		t.Fatal(err)
+	}
+	if f := fs.Lookup("ServerPort"); f == nil || f.DefValue != "8080" || f.Usage != "server port" {
+		t.Fatalf("unexpected flag %v", f)
+	}
+	if fs.Lookup("DataSources.default.DriverName") == nil {
+		t.Fatal("expect prefixed flag")
+	}
+
+	err := fs.Parse([]string{"-ServerPort=9090", "-LogResponse", "-set", "DataSources.default.MaxIdleConn=5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := yfig.New(yfig.WithOverlay(source))
+	err = config.ReadValue(strings.NewReader(`
+ServerPort: 8000
+DataSources:
+  default:
+    DriverName: postgres
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := config.Get("ServerPort", ""); v != "9090" {
+		t.Fatalf("expect 9090 but get %s", v)
+	}
+	port := 0
+	if err := config.GetValue("ServerPort", &port); err != nil || port != 9090 {
+		t.Fatalf("expect 9090 but get %d, err: %v", port, err)
+	}
+	if v := yfig.GetBool(config)("LogResponse", false); !v {
+		t.Fatal("expect LogResponse true")
+	}
+	if v := config.Get("DataSources.default.DriverName", ""); v != "postgres" {
+		t.Fatalf("default flag value must not override, get %s", v)
+	}
+	if v := yfig.GetInt(config)("DataSources.default.MaxIdleConn", 0); v != 5 {
+		t.Fatalf("expect 5 but get %d", v)
+	}
+}
+
+type flagServer struct {
+	Port int    `fig:"port,default=80"`
+	Host string `fig:"host"`
+}
+
+type FlagLog struct {
+	Level string `fig:"log.level"`
+}
+
+type flagNestedStruct struct {
+	FlagLog
+	x       string      `figPx:"app"`
+	Server  flagServer  `fig:"srv"`
+	Backup  *flagServer `fig:"backup"`
+	Verbose bool        `fig:"verbose"`
+}
+
+func TestBindFlagsNested(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := yfig.BindFlags(fs, &flagNestedStruct{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"log.level", "app.srv.port", "app.srv.host", "app.backup.port", "app.verbose"} {
+		if fs.Lookup(name) == nil {
+			t.Fatalf("expect flag %s", name)
+		}
+	}
+	if f := fs.Lookup("app.srv.port"); f.DefValue != "80" {
+		t.Fatalf("expect default 80 but get %s", f.DefValue)
+	}
+	if fs.Lookup("app.srv") != nil {
+		t.Fatal("struct field should not be a flag")
+	}
+
+	source := yfig.NewFlagSource(fs)
+	if err := fs.Parse([]string{"-app.srv.port", "9090"}); err != nil {
+		t.Fatal(err)
+	}
+	config := yfig.New(yfig.WithOverlay(source))
+	if err := config.ReadValue(strings.NewReader("app:\n  srv:\n    port: 8080\n    host: h\n")); err != nil {
+		t.Fatal(err)
+	}
+	test := flagNestedStruct{}
+	if err := yfig.Fill(config, &test); err != nil || test.Server.Port != 9090 || test.Server.Host != "h" {
+		t.Fatalf("unexpected %+v, %v", test, err)
+	}
+}
@@ -0,0 +1,95 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ydx1011/yfig"
+)
+
+func TestLoadByExtension(t *testing.T) {
+	for _, filename := range []string{"test.yaml", "test.toml", "test.properties", "test.ini", "test.env"} {
+		config, err := yfig.Load(filename)
+		if err != nil {
+			t.Fatalf("load %s failed: %s", filename, err)
+		}
+		if config == nil {
+			t.Fatalf("load %s return nil", filename)
+		}
+	}
+	config, err := yfig.Load("test.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := config.Get("Env", ""); v != "dev" {
+		t.Fatalf("expect dev but get %s", v)
+	}
+}
+
+func TestLoadBySniff(t *testing.T) {
+	dir := t.TempDir()
+	contents := map[string]string{
+		"json": `{"ServerPort": 8080}`,
+		"toml": "ServerPort = 8080\n[DataSources]\nDriverName = \"{{ env \"YFIG_NOT_EXIST\" \"mysql\" }}\"",
+		"yaml": "ServerPort: 8080\nDataSources:\n  DriverName: mysql",
+	}
+	for name, content := range contents {
+		filename := filepath.Join(dir, name+".conf")
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		config, err := yfig.Load(filename)
+		if err != nil {
+			t.Fatalf("load %s failed: %s", name, err)
+		}
+		if v := yfig.GetInt(config)("ServerPort", 0); v != 8080 {
+			t.Fatalf("%s: expect 8080 but get %d", name, v)
+		}
+	}
+	if f, ok := yfig.SniffFormat([]byte(contents["toml"])); ok {
+		t.Fatalf("template content should not be sniffed, get %s", f.Name)
+	}
+}
+
+func TestRegisterFormat(t *testing.T) {
+	err := yfig.RegisterFormat(yfig.Format{
+		Name:       "csv",
+		Extensions: []string{"csv"},
+		NewReader:  func() yfig.ValueReader { return yfig.NewPropertiesReader() },
+		NewLoader:  func() yfig.ValueLoader { return yfig.NewJsonLoader() },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if !yfig.UnregisterFormat("csv") {
+			t.Error("expect csv format unregistered")
+		}
+		if _, ok := yfig.LookupFormat("csv"); ok {
+			t.Error("expect csv format removed")
+		}
+	})
+	filename := filepath.Join(t.TempDir(), "config.CSV")
+	if err := os.WriteFile(filename, []byte("a.b=1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := yfig.Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := config.Get("a.b", ""); v != "1" {
+		t.Fatalf("expect 1 but get %s", v)
+	}
+	if _, ok := yfig.LookupFormat("csv"); !ok {
+		t.Fatal("expect registered format")
+	}
+	if err := yfig.RegisterFormat(yfig.Format{Name: "bad"}); err == nil {
+		t.Fatal("expect error")
+	}
+	layered, err := yfig.LoadLayered(yfig.NewFileSource(filename, nil))
+	if err != nil || !strings.Contains(layered.Get("a", ""), "b") {
+		t.Fatalf("expect layered file source by extension, err: %v", err)
+	}
+}
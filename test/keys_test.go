@@ -0,0 +1,83 @@
+package test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestKeys(t *testing.T) {
+	config := loadPathConfig(t)
+	if !config.Has("servers[1].host") || !config.Has(`"a.b".c`) || config.Has("servers[2]") || config.Has("none") {
+		t.Fatal("unexpected Has result")
+	}
+
+	keys := config.Keys("")
+	expect := []string{
+		"DataSources.default.DriverName",
+		"DataSources.default.MaxConn",
+		`"a.b".c`,
+		"server-name",
+		"servers[0].host",
+		"servers[0].port",
+		"servers[1].host",
+		"servers[1].port",
+	}
+	if !reflect.DeepEqual(keys, expect) {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+	for _, k := range keys {
+		if !config.Has(k) {
+			t.Fatalf("key %s should exist", k)
+		}
+	}
+	if keys := config.Keys("servers[1]"); !reflect.DeepEqual(keys, []string{"servers[1].host", "servers[1].port"}) {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+	if keys := config.Keys("none"); len(keys) != 0 {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+
+	if c := config.Children(""); !reflect.DeepEqual(c, []string{"DataSources", `"a.b"`, "server-name", "servers"}) {
+		t.Fatalf("unexpected children %v", c)
+	}
+	if c := config.Children("servers"); !reflect.DeepEqual(c, []string{"[0]", "[1]"}) {
+		t.Fatalf("unexpected children %v", c)
+	}
+	if c := config.Children("server-name"); c != nil {
+		t.Fatalf("unexpected children %v", c)
+	}
+
+	stop := errors.New("stop")
+	var walked []string
+	err := config.Walk(func(key string, value interface{}) error {
+		walked = append(walked, key)
+		if key == "server-name" {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || len(walked) != 4 {
+		t.Fatalf("unexpected walk %v, %v", walked, err)
+	}
+
+	all := config.AllSettings()
+	all["server-name"] = "changed"
+	if v := config.Get("server-name", ""); v != "demo" {
+		t.Fatalf("AllSettings should be a copy, get %s", v)
+	}
+
+	sub := config.Sub("DataSources")
+	if keys := sub.Keys(""); !reflect.DeepEqual(keys, []string{"default.DriverName", "default.MaxConn"}) {
+		t.Fatalf("unexpected sub keys %v", keys)
+	}
+	if !sub.Has("default.MaxConn") || sub.Has("servers") {
+		t.Fatal("unexpected sub Has result")
+	}
+	if m := sub.AllSettings(); len(m) != 1 || m["default"] == nil {
+		t.Fatalf("unexpected sub settings %v", m)
+	}
+	if c := config.Snapshot().Sub("DataSources.default").Children(""); !reflect.DeepEqual(c, []string{"DriverName", "MaxConn"}) {
+		t.Fatalf("unexpected snapshot children %v", c)
+	}
+}
@@ -0,0 +1,106 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ydx1011/yfig"
+)
+
+func TestLayered(t *testing.T) {
+	base := `
+ServerPort: 8080
+Hosts: [a, b]
+DataSources:
+  default:
+    DriverName: mysql
+    MaxIdleConn: 10
+`
+	prod := `
+Hosts: [c]
+DataSources:
+  default:
+    DriverName: postgres
+`
+	config, err := yfig.LoadLayered(
+		yfig.NewReaderSource("base", strings.NewReader(base), yfig.NewYamlReader()),
+		yfig.NewReaderSource("prod", strings.NewReader(prod), yfig.NewYamlReader()),
+		yfig.NewOptionalFileSource("not_exist.yaml", yfig.NewYamlReader()),
+		yfig.NewMapSource("local", yfig.Value{"ServerPort": 9090}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := config.Get("DataSources.default.DriverName", ""); v != "postgres" {
+		t.Fatalf("expect postgres but get %s", v)
+	}
+	if v := yfig.GetInt(config)("DataSources.default.MaxIdleConn", 0); v != 10 {
+		t.Fatalf("expect 10 but get %d", v)
+	}
+	if v := yfig.GetInt(config)("ServerPort", 0); v != 9090 {
+		t.Fatalf("expect 9090 but get %d", v)
+	}
+	if v := yfig.GetStringSlice(config)("Hosts", nil); strings.Join(v, ",") != "c" {
+		t.Fatalf("expect list replaced but get %v", v)
+	}
+
+	origins := map[string]string{
+		"ServerPort":                      "local",
+		"DataSources.default.DriverName":  "prod",
+		"DataSources.default.MaxIdleConn": "base",
+		"Hosts":                           "prod",
+	}
+	for key, expect := range origins {
+		if name, ok := config.Origin(key); !ok || name != expect {
+			t.Fatalf("key %s expect origin %s but get %s", key, expect, name)
+		}
+	}
+	if _, ok := config.Origin("NotExist"); ok {
+		t.Fatal("expect no origin")
+	}
+
+	err = config.ReadValue(strings.NewReader("ServerPort: 7070"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := config.Get("ServerPort", ""); v != "7070" {
+		t.Fatalf("expect 7070 but get %s", v)
+	}
+	if v := config.Get("DataSources.default.DriverName", ""); v != "postgres" {
+		t.Fatalf("expect postgres after reload but get %s", v)
+	}
+}
+
+func TestLayeredReadValue(t *testing.T) {
+	config, err := yfig.LoadLayered(yfig.NewMapSource("base", yfig.Value{"a": 1, "b": 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := config.ReadValue(strings.NewReader("a: 2\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(config.Sources()); n != 2 {
+		t.Fatalf("expect reader layer replaced but get %d sources", n)
+	}
+	if err := config.ReadValue(strings.NewReader("a: [")); err == nil {
+		t.Fatal("expect parse error")
+	}
+	if v := config.Get("a", ""); v != "2" || len(config.Sources()) != 2 {
+		t.Fatalf("expect failed layer rolled back but get %s, %d sources", v, len(config.Sources()))
+	}
+	if err := config.ReadValue(strings.NewReader("a: 3\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if v := config.Get("a", ""); v != "3" || config.Get("b", "") != "1" {
+		t.Fatalf("expect 3 but get %s", v)
+	}
+	if name, _ := config.Origin("a"); name != "reader#1" {
+		t.Fatalf("expect reader#1 but get %s", name)
+	}
+}
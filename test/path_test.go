@@ -0,0 +1,120 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ydx1011/yfig"
+)
+
+const pathYaml = `
+server-name: demo
+servers:
+  - host: a
+    port: 1
+  - host: b
+    port: 2
+"a.b":
+  c: dotted
+DataSources:
+  default:
+    DriverName: mysql
+    MaxConn: 10
+`
+
+func loadPathConfig(tb testing.TB) *yfig.DefaultProperties {
+	config := yfig.New()
+	if err := config.ReadValue(strings.NewReader(pathYaml)); err != nil {
+		tb.Fatal(err)
+	}
+	return config
+}
+
+func TestGetPath(t *testing.T) {
+	config := loadPathConfig(t)
+	cases := map[string]string{
+		"server-name":                    "demo",
+		"servers[1].host":                "b",
+		"servers[0].port":                "1",
+		`"a.b".c`:                        "dotted",
+		`['a.b'].c`:                      "dotted",
+		"DataSources.default.DriverName": "mysql",
+		"servers[2].host":                "none",
+		"servers.host":                   "none",
+		"server-name.x":                  "none",
+		"servers[x]":                     "none",
+		"a..b":                           "none",
+	}
+	for key, expect := range cases {
+		if v := config.Get(key, "none"); v != expect {
+			t.Fatalf("key %s expect %s but get %s", key, expect, v)
+		}
+	}
+
+	port := 0
+	if err := config.GetValue("servers[1].port", &port); err != nil || port != 2 {
+		t.Fatalf("expect 2 but get %d, %v", port, err)
+	}
+	var hosts []struct {
+		Host string `json:"host"`
+	}
+	if err := config.GetValue("servers", &hosts); err != nil || len(hosts) != 2 || hosts[1].Host != "b" {
+		t.Fatalf("unexpected %v, %v", hosts, err)
+	}
+	err := config.GetValue("servers[5]", &port)
+	if !errors.Is(err, yfig.ErrKeyNotFound) {
+		t.Fatalf("expect ErrKeyNotFound but get %v", err)
+	}
+
+	var m map[string]interface{}
+	if err := config.GetValue("DataSources.default", &m); err != nil {
+		t.Fatal(err)
+	}
+	m["DriverName"] = "changed"
+	if v := config.Get("DataSources.default.DriverName", ""); v != "mysql" {
+		t.Fatalf("GetValue result should be a copy, get %s", v)
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	config := loadPathConfig(b)
+	keys := []string{"DataSources.default.DriverName", "DataSources.default.MaxConn"}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		config.Get(keys[i%len(keys)], "")
+	}
+}
+
+func BenchmarkGetValue(b *testing.B) {
+	config := loadPathConfig(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		n := 0
+		if err := config.GetValue("DataSources.default.MaxConn", &n); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// 每次使用新的DefaultProperties（共享属性值）避免命中缓存
+func BenchmarkGetValueUncached(b *testing.B) {
+	value := loadPathConfig(b).AllSettings()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		config := yfig.New(yfig.WithValue(value))
+		var m map[string]interface{}
+		if err := config.GetValue("DataSources.default", &m); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetUncached(b *testing.B) {
+	value := loadPathConfig(b).AllSettings()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		config := yfig.New(yfig.WithValue(value))
+		config.Get("DataSources.default.DriverName", "")
+	}
+}
@@ -0,0 +1,82 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ydx1011/yfig"
+)
+
+func TestPropertiesFile(t *testing.T) {
+	config, err := yfig.LoadPropertiesFile("test.properties")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expects := map[string]string{
+		"ServerPort":                     "8080",
+		"LogResponse":                    "true",
+		"DataSources.default.DriverName": "mysql",
+		"DataSources.default.Dsn":        "user:pass@tcp(localhost)/db?charset=utf8",
+		"Version":                        "0123",
+	}
+	for key, expect := range expects {
+		if v := config.Get(key, ""); v != expect {
+			t.Fatalf("key %s expect %s but get %s", key, expect, v)
+		}
+	}
+	if v := yfig.GetInt(config)("ServerPort", 0); v != 8080 {
+		t.Fatalf("expect 8080 but get %d", v)
+	}
+	m := yfig.GetStringMap(config)("", nil)
+	if m["key with spaces"] != "中文" || m["escaped=key"] != "a\tb" {
+		t.Fatalf("unexpected escaped keys %v", m)
+	}
+
+	// 同时作为值与前缀的key，值保存在DottedValueKey下，与顺序无关
+	for _, s := range []string{"log=INFO\nlog.file=x.log", "log.file=x.log\nlog=INFO"} {
+		v, err := yfig.NewPropertiesReader().Read(strings.NewReader(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		config := yfig.New(yfig.WithValue(*v))
+		if config.Get("log._value", "") != "INFO" || config.Get("log.file", "") != "x.log" {
+			t.Fatalf("%q: unexpected %v", s, *v)
+		}
+	}
+}
+
+func TestIniFile(t *testing.T) {
+	config, err := yfig.LoadIniFile("test.ini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expects := map[string]string{
+		"ServerPort":                     "8080",
+		"DataSources.default.DriverName": "mysql",
+		"DataSources.default.Password":   "p;a#ss",
+		"DataSources.default.pool.size":  "5",
+		"Redis.Addr":                     "localhost:6379",
+		"Redis.Dsn":                      "a,b",
+	}
+	for key, expect := range expects {
+		if v := config.Get(key, ""); v != expect {
+			t.Fatalf("key %s expect %s but get %s", key, expect, v)
+		}
+	}
+	if v := yfig.GetInt(config)("DataSources.default.MaxIdleConn", 0); v != 10 {
+		t.Fatalf("expect 10 but get %d", v)
+	}
+
+	_, err = yfig.NewIniReader().Read(strings.NewReader("[a\nb=1"))
+	if err == nil {
+		t.Fatal("expect invalid section error")
+	}
+
+	v, err := yfig.NewIniReader().Read(strings.NewReader("log=INFO\n[log]\nfile=x.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log, _ := (*v)["log"].(map[string]interface{}); log[yfig.DottedValueKey] != "INFO" || log["file"] != "x.log" {
+		t.Fatalf("unexpected %v", *v)
+	}
+}
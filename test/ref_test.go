@@ -0,0 +1,143 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ydx1011/yfig"
+)
+
+func TestRef(t *testing.T) {
+	yaml := `
+DataSources:
+  default:
+    Host: db.local
+    Port: 3306
+    Url: "${DataSources.default.Host}:${DataSources.default.Port}"
+  read:
+    Url: "${DataSources.default.Url}"
+    Port: "${DataSources.default.Port}"
+Hosts: [a, b]
+AllHosts: "${Hosts}"
+First: "host-${Hosts[0]}"
+Escaped: "$${DataSources.default.Host}"
+`
+	config := yfig.New(yfig.WithReferences())
+	if err := config.ReadValue(strings.NewReader(yaml)); err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{
+		"DataSources.default.Url": "db.local:3306",
+		"DataSources.read.Url":    "db.local:3306",
+		"First":                   "host-a",
+		"Escaped":                 "${DataSources.default.Host}",
+	}
+	for key, expect := range cases {
+		if v := config.Get(key, ""); v != expect {
+			t.Fatalf("key %s expect %s but get %s", key, expect, v)
+		}
+	}
+	port := 0
+	if err := config.GetValue("DataSources.read.Port", &port); err != nil || port != 3306 {
+		t.Fatalf("expect 3306 but get %d, %v", port, err)
+	}
+	test := struct {
+		AllHosts []string `fig:"AllHosts"`
+		Url      string   `fig:"DataSources.read.Url"`
+	}{}
+	if err := yfig.Fill(config, &test); err != nil || strings.Join(test.AllHosts, ",") != "a,b" || test.Url != "db.local:3306" {
+		t.Fatalf("unexpected %+v, %v", test, err)
+	}
+
+	// Get在当前属性值上解析
+	if err := config.Set("DataSources.default.Host", "db.prod"); err != nil {
+		t.Fatal(err)
+	}
+	if v := config.Get("DataSources.read.Url", ""); v != "db.prod:3306" {
+		t.Fatalf("expect db.prod:3306 but get %s", v)
+	}
+	if v := config.Sub("DataSources.read").Get("Url", ""); v != "db.prod:3306" {
+		t.Fatalf("expect db.prod:3306 but get %s", v)
+	}
+	if settings := config.AllSettings(); settings["First"] != "host-${Hosts[0]}" {
+		t.Fatalf("expect unresolved reference but get %v", settings["First"])
+	}
+
+	if err := config.Set("DataSources.default.Host", "${DataSources.read.Url}"); !errors.Is(err, yfig.ErrReferenceCycle) {
+		t.Fatalf("expect reference cycle but get %v", err)
+	}
+	if v := config.Get("DataSources.default.Host", ""); v != "db.prod" {
+		t.Fatalf("expect db.prod but get %s", v)
+	}
+}
+
+func TestRefError(t *testing.T) {
+	cases := map[string]string{
+		"A: ${B}\nB: ${A}\n":                "key B: reference cycle: A -> B -> A",
+		"A:\n  B: ${A}\n":                   "key A.B: reference cycle: A -> A.B -> A",
+		"A: x\nB: \"x-${C.D}\"\n":           "key B: reference ${C.D}: ",
+		"A: [1]\nB: \"x-${A}\"\n":           "key B: reference ${A} is not a scalar value",
+		"A: x\nB: \"x-${A\"\n":              "key B: unterminated reference",
+		"A: x\nB: \"${secret:env:NONE}\"\n": "",
+	}
+	for yaml, expect := range cases {
+		err := yfig.New(yfig.WithReferences()).ReadValue(strings.NewReader(yaml))
+		if expect == "" {
+			if err != nil {
+				t.Fatalf("%q: unexpected %v", yaml, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), expect) {
+			t.Fatalf("%q: expect %s but get %v", yaml, expect, err)
+		}
+	}
+}
+
+func TestRefLayered(t *testing.T) {
+	base := `
+Host: localhost
+Url: "http://${Host}:${Port}"
+Port: 8080
+`
+	prod := `
+Host: example.com
+`
+	config := yfig.NewLayeredWithOpts([]yfig.Source{
+		yfig.NewReaderSource("base", strings.NewReader(base), yfig.NewYamlReader()),
+		yfig.NewReaderSource("prod", strings.NewReader(prod), yfig.NewYamlReader()),
+	}, []yfig.Opt{yfig.WithReferences()})
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if v := config.Get("Url", ""); v != "http://example.com:8080" {
+		t.Fatalf("expect http://example.com:8080 but get %s", v)
+	}
+
+	config.AddSource(yfig.NewMapSource("local", yfig.Value{"Port": "${Url}"}))
+	if err := config.Load(); !errors.Is(err, yfig.ErrReferenceCycle) {
+		t.Fatalf("expect reference cycle but get %v", err)
+	}
+	if v := config.Get("Url", ""); v != "http://example.com:8080" {
+		t.Fatalf("expect previous value kept but get %s", v)
+	}
+}
+
+func TestRefDisabled(t *testing.T) {
+	config := yfig.New()
+	if err := config.ReadValue(strings.NewReader("Host: x\nCmd: \"echo ${HOME} ${Host} $${Host}\"\n")); err != nil {
+		t.Fatal(err)
+	}
+	if v := config.Get("Cmd", ""); v != "echo ${HOME} ${Host} $${Host}" {
+		t.Fatalf("expect literal value but get %s", v)
+	}
+
+	config = yfig.New(yfig.WithValue(yfig.Value{"A": "${B}", "B": "b"}), yfig.WithReferences())
+	if v := config.Get("A", ""); v != "b" {
+		t.Fatalf("expect b but get %s", v)
+	}
+	if config := yfig.New(yfig.WithValue(yfig.Value{"A": "${C}"}), yfig.WithReferences()); config != nil {
+		t.Fatal("expect missing reference error")
+	}
+}
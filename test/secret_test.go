@@ -0,0 +1,78 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ydx1011/yfig"
+)
+
+func TestSecret(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "db"), []byte("file-pass\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("YFIG_TEST_SECRET", "env-pass")
+	defer os.Unsetenv("YFIG_TEST_SECRET")
+
+	vault := yfig.NewMemorySecretProvider(map[string]string{"db/app#password": "v1"})
+	config := yfig.New(
+		yfig.WithSecretProvider("vault", vault),
+		yfig.WithSecretTTL(50*time.Millisecond),
+	)
+	yaml := `
+File: "{{ secret "file:` + filepath.Join(dir, "db") + `" }}"
+Env: "${secret:env:YFIG_TEST_SECRET}"
+Dsn: "app:${secret:vault:db/app#password}@tcp(db)/app"
+Nested:
+  Password: "${secret:vault:db/app#password}"
+Missing: "${secret:vault:none}"
+`
+	if err := config.ReadValue(strings.NewReader(yaml)); err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{
+		"File":    "file-pass",
+		"Env":     "env-pass",
+		"Dsn":     "app:v1@tcp(db)/app",
+		"Missing": "default",
+	}
+	for key, expect := range cases {
+		if v := config.Get(key, "default"); v != expect {
+			t.Fatalf("key %s expect %s but get %s", key, expect, v)
+		}
+	}
+	test := struct {
+		Password string `fig:"Nested.Password"`
+	}{}
+	if err := yfig.Fill(config, &test); err != nil || test.Password != "v1" {
+		t.Fatalf("unexpected %+v, %v", test, err)
+	}
+	var m map[string]interface{}
+	if err := config.GetValue("Nested", &m); err != nil || m["Password"] != "v1" {
+		t.Fatalf("unexpected %v, %v", m, err)
+	}
+	s := ""
+	if err := config.GetValue("Missing", &s); err == nil {
+		t.Fatal("expect unresolved secret error")
+	}
+
+	vault.Set("db/app#password", "v2")
+	if v := config.Get("Nested.Password", ""); v != "v1" {
+		t.Fatalf("expect cached v1 but get %s", v)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if v := config.Get("Nested.Password", ""); v != "v2" {
+		t.Fatalf("expect v2 after ttl but get %s", v)
+	}
+	if out, _ := config.Dump(yfig.DumpFlat); strings.Contains(out, "v2") {
+		t.Fatalf("dump should not resolve secrets:\n%s", out)
+	}
+
+	if err := yfig.New().ReadValue(strings.NewReader(`a: "{{ secret "vault:x" }}"`)); err == nil {
+		t.Fatal("expect unknown provider error")
+	}
+}
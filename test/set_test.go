@@ -0,0 +1,176 @@
+package test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ydx1011/yfig"
+)
+
+const setYaml = `# app config
+ServerPort: 8080 # http port
+
+# data sources
+DataSources:
+  default:
+    DriverName: mysql
+    # max connections
+    MaxConn: 10
+servers:
+  - host: a
+    port: 1
+Name: demo
+`
+
+func TestSet(t *testing.T) {
+	config := yfig.New()
+	if err := config.ReadValue(strings.NewReader(setYaml)); err != nil {
+		t.Fatal(err)
+	}
+	if config.Get("DataSources.default.MaxConn", "") != "10" {
+		t.Fatal("expect 10")
+	}
+
+	var events []string
+	config.OnChange("DataSources", func(e yfig.ChangeEvent) {
+		events = append(events, e.Key)
+	})
+	if err := config.Set("DataSources.default.MaxConn", 20); err != nil {
+		t.Fatal(err)
+	}
+	if v := config.Get("DataSources.default.MaxConn", ""); v != "20" {
+		t.Fatalf("expect 20 but get %s", v)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expect 1 event but get %v", events)
+	}
+	if err := config.Set("Redis.Addr", "127.0.0.1:6379"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Set("servers[1]", map[string]interface{}{"host": "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if v := config.Get("servers[1].host", ""); v != "b" {
+		t.Fatalf("expect b but get %s", v)
+	}
+	if err := config.Set("servers[5].host", "x"); err == nil {
+		t.Fatal("expect index out of range error")
+	}
+	if err := config.Set("", 1); err == nil {
+		t.Fatal("expect empty key error")
+	}
+
+	if err := config.Delete("servers[0]"); err != nil {
+		t.Fatal(err)
+	}
+	if v := config.Get("servers[0].host", ""); v != "b" {
+		t.Fatalf("expect b but get %s", v)
+	}
+	if err := config.Delete("Name"); err != nil || config.Has("Name") {
+		t.Fatalf("delete failed: %v", err)
+	}
+	version := config.Version()
+	if err := config.Delete("None.x"); err != nil || config.Version() != version {
+		t.Fatalf("deleting missing key should not change properties: %v", err)
+	}
+
+	if err := config.SetDefault("Timeout", "3s"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SetDefault("ServerPort", 9090); err != nil {
+		t.Fatal(err)
+	}
+	if v := config.Get("Timeout", ""); v != "3s" {
+		t.Fatalf("expect 3s but get %s", v)
+	}
+	if v := config.Get("ServerPort", ""); v != "8080" {
+		t.Fatalf("expect 8080 but get %s", v)
+	}
+	if err := config.ReadValue(strings.NewReader("Name: reloaded\n")); err != nil {
+		t.Fatal(err)
+	}
+	if v := config.Get("Timeout", ""); v != "3s" {
+		t.Fatalf("default should be applied after reload, get %s", v)
+	}
+	if v := config.Get("ServerPort", ""); v != "9090" {
+		t.Fatalf("expect 9090 but get %s", v)
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	config := yfig.New()
+	if err := config.ReadValue(strings.NewReader(setYaml)); err != nil {
+		t.Fatal(err)
+	}
+	config.Set("DataSources.default.MaxConn", 20)
+	config.Set("Added", true)
+	config.Delete("Name")
+
+	buf := bytes.NewBuffer(nil)
+	if _, err := config.WriteTo(buf); err != nil {
+		t.Fatal(err)
+	}
+	expect := `# app config
+ServerPort: 8080 # http port
+
+# data sources
+DataSources:
+  default:
+    DriverName: mysql
+    # max connections
+    MaxConn: 20
+servers:
+- host: a
+  port: 1
+Added: true
+`
+	if buf.String() != expect {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	if err := config.SaveFile(filename); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := yfig.LoadYamlFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := saved.Get("DataSources.default.MaxConn", ""); v != "20" {
+		t.Fatalf("expect 20 but get %s", v)
+	}
+	if data, _ := os.ReadFile(filename); string(data) != expect {
+		t.Fatalf("unexpected file content:\n%s", data)
+	}
+
+	layered, err := yfig.LoadLayered(yfig.NewMapSource("base", yfig.Value{"a": 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	layered.Set("b.c", "x")
+	if name, _ := layered.Origin("b.c"); name != yfig.SetOrigin {
+		t.Fatalf("expect origin %s but get %s", yfig.SetOrigin, name)
+	}
+	buf.Reset()
+	layered.WriteTo(buf)
+	if buf.String() != "a: 1\nb:\n  c: x\n" {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+
+	// 多行字符串中的空行
+	config = yfig.New()
+	if err := config.ReadValue(strings.NewReader("# c\na: 1\nb: |\n  x\n\n  y\n# d\nc: 3\n")); err != nil {
+		t.Fatal(err)
+	}
+	config.Set("a", 2)
+	buf.Reset()
+	if _, err := config.WriteTo(buf); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "# c\na: 2\nb: |\n  x\n\n  y\n# d\nc: 3\n"; buf.String() != expect {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+}
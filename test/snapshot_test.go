@@ -0,0 +1,54 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ydx1011/yfig"
+)
+
+func TestSnapshot(t *testing.T) {
+	config := yfig.New()
+	if err := config.ReadValue(strings.NewReader("ServerPort: 8080\nName: a\n")); err != nil {
+		t.Fatal(err)
+	}
+	snap := config.Snapshot()
+	if snap.Version() != config.Version() {
+		t.Fatalf("expect version %d but get %d", config.Version(), snap.Version())
+	}
+	hash := snap.Hash()
+
+	if err := config.ReadValue(strings.NewReader("ServerPort: 9090\nName: b\n")); err != nil {
+		t.Fatal(err)
+	}
+	if v := snap.Get("ServerPort", ""); v != "8080" {
+		t.Fatalf("snapshot expect 8080 but get %s", v)
+	}
+	test := struct {
+		Port int    `fig:"ServerPort"`
+		Name string `fig:"Name"`
+	}{}
+	if err := yfig.Fill(snap, &test); err != nil || test.Port != 8080 || test.Name != "a" {
+		t.Fatalf("unexpected %+v, %v", test, err)
+	}
+	if v := config.Get("ServerPort", ""); v != "9090" {
+		t.Fatalf("expect 9090 but get %s", v)
+	}
+	if snap.Version() >= config.Version() {
+		t.Fatalf("expect version increased: %d %d", snap.Version(), config.Version())
+	}
+	if snap.Hash() == config.Snapshot().Hash() {
+		t.Fatal("expect different hash")
+	}
+
+	if err := config.ReadValue(strings.NewReader("Name: a\nServerPort: 8080\n")); err != nil {
+		t.Fatal(err)
+	}
+	if config.Snapshot().Hash() != hash {
+		t.Fatal("expect same hash for same content")
+	}
+	if err := snap.ReadValue(strings.NewReader("Name: c")); !errors.Is(err, yfig.ErrImmutable) {
+		t.Fatalf("expect ErrImmutable but get %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ydx1011/yfig"
+)
+
+const subYaml = `
+DataSources:
+  default:
+    DriverName: mysql
+    MaxConn: 10
+    Hosts:
+      - a
+      - b
+Redis:
+  Addr: 127.0.0.1:6379
+`
+
+func TestSub(t *testing.T) {
+	config := yfig.New()
+	if err := config.ReadValue(strings.NewReader(subYaml)); err != nil {
+		t.Fatal(err)
+	}
+	sub := config.Sub("DataSources.default")
+	if v := sub.Get("DriverName", ""); v != "mysql" {
+		t.Fatalf("expect mysql but get %s", v)
+	}
+	if v := sub.Get("Hosts[1]", ""); v != "b" {
+		t.Fatalf("expect b but get %s", v)
+	}
+	n := 0
+	if err := sub.GetValue("MaxConn", &n); err != nil || n != 10 {
+		t.Fatalf("expect 10 but get %d, %v", n, err)
+	}
+	test := struct {
+		DriverName string   `fig:"DriverName"`
+		MaxConn    int      `fig:"MaxConn"`
+		Hosts      []string `fig:"Hosts"`
+	}{}
+	if err := yfig.Fill(sub, &test); err != nil || test.DriverName != "mysql" || len(test.Hosts) != 2 {
+		t.Fatalf("unexpected %+v, %v", test, err)
+	}
+	if v := config.Sub("DataSources").Sub("default").Get("MaxConn", ""); v != "10" {
+		t.Fatalf("expect 10 but get %s", v)
+	}
+
+	var keys []string
+	cancel := sub.OnChange("MaxConn", func(e yfig.ChangeEvent) {
+		keys = append(keys, e.Key)
+	})
+	defer cancel()
+	var all []string
+	sub.OnChange("", func(e yfig.ChangeEvent) {
+		all = append(all, e.Key)
+	})
+
+	snap := sub.Snapshot()
+	hash := snap.Hash()
+	if err := config.ReadValue(strings.NewReader(strings.Replace(subYaml, "6379", "6380", 1))); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 || len(all) != 0 {
+		t.Fatalf("unexpected events %v %v", keys, all)
+	}
+	if snap.Hash() != hash || snap.Hash() == config.Snapshot().Hash() {
+		t.Fatal("snapshot hash should only depend on the subtree")
+	}
+
+	if err := config.ReadValue(strings.NewReader(strings.Replace(subYaml, "10", "20", 1))); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0] != "MaxConn" || len(all) != 1 || all[0] != "" {
+		t.Fatalf("unexpected events %v %v", keys, all)
+	}
+	if v := sub.Get("MaxConn", ""); v != "20" {
+		t.Fatalf("expect 20 but get %s", v)
+	}
+	if v := snap.Get("MaxConn", ""); v != "10" {
+		t.Fatalf("snapshot expect 10 but get %s", v)
+	}
+}
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ydx1011/yfig"
+)
+
+type tagStruct struct {
+	Name     string        `fig:"name,default=a\\,b"`
+	Port     int           `fig:"port,default=8080"`
+	Timeout  time.Duration `fig:"timeout,default=3s"`
+	Host     string        `fig:"host,env=YFIG_TAG_TEST_HOST,default=localhost"`
+	Hosts    []string      `fig:"hosts,sep=;"`
+	Ports    []int         `fig:"ports,default=1;2,sep=;"`
+	StartAt  time.Time     `fig:"startAt,layout=2006/01/02"`
+	Password string        `fig:"password,secret" validate:"min=8"`
+}
+
+func TestFillTagOptions(t *testing.T) {
+	os.Setenv("YFIG_TAG_TEST_HOST", "example.com")
+	defer os.Unsetenv("YFIG_TAG_TEST_HOST")
+
+	config := yfig.New()
+	err := config.ReadValue(strings.NewReader(`
+hosts: a; b;c
+startAt: 2026/01/02
+password: secret
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	test := tagStruct{}
+	err = yfig.Fill(config, &test)
+	var errs yfig.Errors
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("expect 1 error but get %v", err)
+	}
+	fe := errs[0].(*yfig.FieldError)
+	if fe.Value != yfig.Redacted || strings.Contains(fe.Error(), "secret") {
+		t.Fatalf("secret value not redacted: %v", fe)
+	}
+	if test.Name != "a,b" || test.Port != 8080 || test.Timeout != 3*time.Second {
+		t.Fatalf("default not used: %+v", test)
+	}
+	if test.Host != "example.com" {
+		t.Fatalf("expect example.com but get %s", test.Host)
+	}
+	if strings.Join(test.Hosts, ",") != "a,b,c" {
+		t.Fatalf("expect [a b c] but get %v", test.Hosts)
+	}
+	if len(test.Ports) != 2 || test.Ports[1] != 2 {
+		t.Fatalf("expect [1 2] but get %v", test.Ports)
+	}
+	if !test.StartAt.Equal(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected time %v", test.StartAt)
+	}
+}
+
+func TestFillTagRequired(t *testing.T) {
+	config := yfig.New()
+	if err := config.ReadValue(strings.NewReader(`name: a`)); err != nil {
+		t.Fatal(err)
+	}
+	test := struct {
+		Name string `fig:"name,required"`
+		Port int    `fig:"port,required"`
+	}{}
+	err := yfig.Fill(config, &test)
+	var errs yfig.Errors
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("expect 1 error but get %v", err)
+	}
+	if ke, ok := errs[0].(*yfig.KeyError); !ok || ke.Key != "port" || !errors.Is(ke, yfig.ErrKeyNotFound) {
+		t.Fatalf("unexpected error %v", errs[0])
+	}
+	if test.Name != "a" {
+		t.Fatalf("expect a but get %s", test.Name)
+	}
+}
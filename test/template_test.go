@@ -0,0 +1,92 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/ydx1011/yfig"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cert"), []byte("CERT\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	hostname, _ := os.Hostname()
+	config := yfig.New(yfig.WithTemplateFuncs(template.FuncMap{
+		"prefix": func(s string) string { return "app-" + s },
+	}))
+	yaml := `
+Host: '{{ env "YFIG_TEST_NONE" "" | default "LocalHost" | lower }}'
+Upper: '{{ "abc" | upper }}'
+Trim: '{{ "  x  " | trim }}'
+Cert: '{{ file "` + filepath.Join(dir, "cert") + `" | trim }}'
+Encoded: '{{ "hello" | base64enc }}'
+Decoded: '{{ "aGVsbG8=" | base64dec }}'
+Joined: '{{ "a,b,c" | split "," | join ";" }}'
+Json: '{{ "a,b" | split "," | toJson }}'
+Yaml: '{{ "a" | toYaml }}'
+Hostname: '{{ hostname }}'
+Year: '{{ now.Year }}'
+Workers: {{ mul 2 (add 1 "3") }}
+Mod: {{ mod 7 (sub 5 2) }}
+Div: {{ div 7 2 }}
+Name: '{{ prefix "demo" }}'
+`
+	if err := config.ReadValue(strings.NewReader(yaml)); err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{
+		"Host":     "localhost",
+		"Upper":    "ABC",
+		"Trim":     "x",
+		"Cert":     "CERT",
+		"Encoded":  "aGVsbG8=",
+		"Decoded":  "hello",
+		"Joined":   "a;b;c",
+		"Json":     `["a","b"]`,
+		"Yaml":     "a",
+		"Hostname": hostname,
+		"Workers":  "8",
+		"Mod":      "1",
+		"Div":      "3",
+		"Name":     "app-demo",
+	}
+	for key, expect := range cases {
+		if v := config.Get(key, ""); v != expect {
+			t.Fatalf("key %s expect %s but get %s", key, expect, v)
+		}
+	}
+	if len(config.Get("Year", "")) != 4 {
+		t.Fatalf("unexpected year %s", config.Get("Year", ""))
+	}
+
+	err := yfig.New().ReadValue(strings.NewReader(`a: '{{ env "YFIG_TEST_NONE" "" | required "YFIG_TEST_NONE is required" }}'`))
+	if err == nil || !strings.Contains(err.Error(), "YFIG_TEST_NONE is required") {
+		t.Fatalf("expect required error but get %v", err)
+	}
+	if err := yfig.New().ReadValue(strings.NewReader(`a: {{ div 1 0 }}`)); err == nil {
+		t.Fatal("expect division by zero error")
+	}
+}
+
+func TestTemplateFuncsInvalid(t *testing.T) {
+	invalid := []template.FuncMap{
+		{"x": 1},
+		{"x": nil},
+		{"x": func() {}},
+		{"x": func() (string, string) { return "", "" }},
+		{"a-b": func() string { return "" }},
+	}
+	for _, funcs := range invalid {
+		if config := yfig.New(yfig.WithTemplateFuncs(funcs)); config != nil {
+			t.Fatalf("expect invalid funcs %v", funcs)
+		}
+	}
+	if config := yfig.New(yfig.WithTemplateFuncs(template.FuncMap{"x": func() (int, error) { return 1, nil }})); config == nil {
+		t.Fatal("expect valid funcs")
+	}
+}
@@ -0,0 +1,98 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ydx1011/yfig"
+)
+
+type tomlServer struct {
+	Host  string `json:"host"`
+	Ports []int  `json:"ports"`
+}
+
+type tomlStruct struct {
+	Port       int          `fig:"ServerPort"`
+	FloatValue float32      `fig:"Value.float"`
+	DriverName string       `fig:"DataSources.default.DriverName"`
+	Servers    []tomlServer `fig:"Servers"`
+}
+
+func TestToml(t *testing.T) {
+	config, err := yfig.LoadTomlFile("test.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := config.Get("DataSources.default.DriverName", ""); v != "mysql" {
+		t.Fatalf("expect mysql but get %s", v)
+	}
+	if v := yfig.GetInt64(config)("Value.big", 0); v != 9223372036854775807 {
+		t.Fatalf("expect max int64 but get %d", v)
+	}
+	if v := yfig.GetInt(config)("Value.hex", 0); v != 255 {
+		t.Fatalf("expect 255 but get %d", v)
+	}
+	if v := config.Get("DataSources.default.Dsn", ""); v != "user:pass@tcp(localhost)/db" {
+		t.Fatalf("unexpected dsn %s", v)
+	}
+	if v := yfig.GetTime(config)("DataSources.default.StartAt", time.Time{}); v.Year() != 1979 {
+		t.Fatalf("unexpected time %s", v)
+	}
+	if v := config.Get("Servers", ""); !strings.Contains(v, "z1") {
+		t.Fatalf("unexpected servers %s", v)
+	}
+
+	test := tomlStruct{}
+	if err := yfig.Fill(config, &test); err != nil {
+		t.Fatal(err)
+	}
+	if test.Port != 8080 || test.FloatValue != 1.5 || len(test.Servers) != 2 || test.Servers[0].Ports[1] != 8002 {
+		t.Fatalf("unexpected fill result %+v", test)
+	}
+}
+
+func TestTomlRoundTrip(t *testing.T) {
+	config, err := yfig.LoadTomlFile("test.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	loader := yfig.NewTomlLoader()
+	s, err := loader.Serialize(config.AllSettings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := yfig.NewTomlReader().Read(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("%s\n%s", err, s)
+	}
+	again, err := loader.Serialize(*v)
+	if err != nil || again != s {
+		t.Fatalf("round trip mismatch, err: %v\n%s\n%s", err, s, again)
+	}
+}
+
+func TestTomlInvalid(t *testing.T) {
+	invalid := []string{
+		"a = 1\na = 2",
+		"[a]\n[a]",
+		"a = 01",
+		"a = \"unterminated",
+		"a = { b = 1 }\n[a]",
+		"a = [1, 2]\n[[a]]",
+		"a = 1 b = 2",
+		"a = 1__0",
+		"[a]\nb.c = 1\n[a.b]\nd = 1",
+		"a.b.c = 1\n[a.b]",
+	}
+	for _, s := range invalid {
+		if _, err := yfig.NewTomlReader().Read(strings.NewReader(s)); err == nil {
+			t.Fatalf("expect error for %q", s)
+		}
+	}
+	// 带"."的key定义的表可以再定义子表
+	if _, err := yfig.NewTomlReader().Read(strings.NewReader("[a]\nb.c = 1\n[a.b.d]\ne = 1")); err != nil {
+		t.Fatal(err)
+	}
+}
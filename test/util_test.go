@@ -0,0 +1,92 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ydx1011/yfig"
+)
+
+const getterYaml = `
+ServerPort: 8080
+LogResponse: "on"
+Big: 18446744073709551615
+Hex: "0x10"
+Negative: -1
+Value:
+  float: 1.5
+Timeout: 1m30s
+StartAt: "2026-01-01T00:00:00Z"
+Hosts: [a, b, c]
+CsvHosts: "a, b"
+DataSources:
+  default:
+    DriverName: mysql
+`
+
+func loadGetterConfig(t *testing.T) yfig.Properties {
+	config := yfig.New()
+	if err := config.ReadValue(strings.NewReader(getterYaml)); err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+func TestGetters(t *testing.T) {
+	config := loadGetterConfig(t)
+
+	if v := yfig.GetInt(config)("ServerPort", 0); v != 8080 {
+		t.Fatalf("expect 8080 but get %d", v)
+	}
+	if v := yfig.GetString(config)("ServerPort", ""); v != "8080" {
+		t.Fatalf("expect 8080 but get %s", v)
+	}
+	if v := yfig.GetBool(config)("LogResponse", false); !v {
+		t.Fatal("expect true")
+	}
+	if v := yfig.GetInt(config)("Hex", 0); v != 16 {
+		t.Fatalf("expect 16 but get %d", v)
+	}
+	if v := yfig.GetFloat32(config)("Value.float", 0); v != 1.5 {
+		t.Fatalf("expect 1.5 but get %f", v)
+	}
+	if v := yfig.GetDuration(config)("Timeout", 0); v != 90*time.Second {
+		t.Fatalf("expect 90s but get %s", v)
+	}
+	if v := yfig.GetTime(config)("StartAt", time.Time{}); !v.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected time %s", v)
+	}
+	if v := yfig.GetStringSlice(config)("Hosts", nil); strings.Join(v, "|") != "a|b|c" {
+		t.Fatalf("unexpected slice %v", v)
+	}
+	if v := yfig.GetStringSlice(config)("CsvHosts", nil); strings.Join(v, "|") != "a|b" {
+		t.Fatalf("unexpected slice %v", v)
+	}
+	if v := yfig.GetStringMap(config)("DataSources.default", nil); v["DriverName"] != "mysql" {
+		t.Fatalf("unexpected map %v", v)
+	}
+	if v := yfig.GetInt(config)("NotExist", 7); v != 7 {
+		t.Fatalf("expect default 7 but get %d", v)
+	}
+}
+
+func TestGettersError(t *testing.T) {
+	config := loadGetterConfig(t)
+
+	if _, err := yfig.GetUint64E(config)("Negative"); err == nil {
+		t.Fatal("expect negative uint64 error")
+	}
+	if _, err := yfig.GetIntE(config)("Value.float"); err == nil {
+		t.Fatal("expect non-integral int error")
+	}
+	if _, err := yfig.GetBoolE(config)("DataSources.default.DriverName"); err == nil {
+		t.Fatal("expect bool conversion error")
+	}
+	if _, err := yfig.GetStringE(config)("NotExist"); err == nil {
+		t.Fatal("expect not found error")
+	}
+	if v := yfig.GetUint(config)("Negative", 3); v != 3 {
+		t.Fatalf("expect default 3 but get %d", v)
+	}
+}
@@ -0,0 +1,105 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ydx1011/yfig"
+)
+
+type validateStruct struct {
+	Port       int           `fig:"ServerPort" validate:"required,min=1,max=65535"`
+	Env        string        `fig:"Env" validate:"oneof=dev test prod"`
+	Name       string        `fig:"Name" validate:"regexp=^[a-z]{1\\,3}$"`
+	Endpoint   string        `fig:"Endpoint" validate:"url"`
+	Addr       string        `fig:"Addr" validate:"hostport"`
+	Timeout    time.Duration `fig:"Timeout" validate:"min=1s"`
+	Interval   string        `fig:"Interval" validate:"duration"`
+	Hosts      []string      `fig:"Hosts" validate:"min=1"`
+	Optional   string        `fig:"Optional" validate:"url"`
+	x          string        `figPx:"DataSources.default"`
+	DriverName string        `fig:"DriverName" validate:"required"`
+}
+
+func TestValidateOnFill(t *testing.T) {
+	config := yfig.New()
+	err := config.ReadValue(strings.NewReader(`
+ServerPort: 70000
+Env: staging
+Name: abcd
+Endpoint: not-a-url
+Addr: localhost
+Timeout: 1000
+Interval: 5x
+Hosts: []
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	test := validateStruct{}
+	err = yfig.Fill(config, &test)
+	if err == nil {
+		t.Fatal("expect validation error")
+	}
+	var errs yfig.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expect Errors but get %T", err)
+	}
+	failed := map[string]string{}
+	for _, e := range errs {
+		fe, ok := e.(*yfig.FieldError)
+		if !ok {
+			t.Fatalf("expect FieldError but get %T", e)
+		}
+		failed[fe.Field] = fe.Key
+	}
+	expects := map[string]string{
+		"Port":       "ServerPort",
+		"Env":        "Env",
+		"Name":       "Name",
+		"Endpoint":   "Endpoint",
+		"Addr":       "Addr",
+		"Timeout":    "Timeout",
+		"Interval":   "Interval",
+		"Hosts":      "Hosts",
+		"DriverName": "DataSources.default.DriverName",
+	}
+	if len(failed) != len(expects) {
+		t.Fatalf("unexpected failed fields %v", failed)
+	}
+	for field, key := range expects {
+		if failed[field] != key {
+			t.Fatalf("field %s expect key %s but get %s", field, key, failed[field])
+		}
+	}
+}
+
+func TestValidatePass(t *testing.T) {
+	test := validateStruct{
+		Port:       8080,
+		Env:        "prod",
+		Name:       "abc",
+		Endpoint:   "https://example.com/x",
+		Addr:       "localhost:8080",
+		Timeout:    time.Minute,
+		Interval:   "5s",
+		Hosts:      []string{"a"},
+		DriverName: "mysql",
+	}
+	if err := yfig.Validate(&test); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateUnexported(t *testing.T) {
+	test := struct {
+		n   int    `validate:"min=1"`
+		env string `validate:"oneof=dev prod"`
+	}{env: "test"}
+	err := yfig.Validate(&test)
+	if err == nil || !strings.Contains(err.Error(), "value 0 is less than 1") || !strings.Contains(err.Error(), "value test is not one of") {
+		t.Fatalf("unexpected %v", err)
+	}
+}
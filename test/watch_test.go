@@ -0,0 +1,124 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/ydx1011/yfig"
+)
+
+func TestWatcher(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(filename, []byte("ServerPort: 8080\nDataSources:\n  default:\n    DriverName: mysql\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := yfig.LoadYamlFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prop := config.(*yfig.DefaultProperties)
+
+	events := make(chan yfig.ChangeEvent, 10)
+	prop.OnChange("DataSources", func(e yfig.ChangeEvent) {
+		events <- e
+	})
+	portEvents := make(chan yfig.ChangeEvent, 10)
+	prop.OnChange("ServerPort", func(e yfig.ChangeEvent) {
+		portEvents <- e
+	})
+
+	watcher := yfig.NewFileWatcher(config, filename)
+	watcher.Interval = 10 * time.Millisecond
+	watcher.UseNotify = runtime.GOOS == "linux"
+	errs := make(chan error, 10)
+	watcher.OnError = func(err error) {
+		errs <- err
+	}
+	if err := watcher.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Stop()
+
+	if err := os.WriteFile(filename, []byte("ServerPort: 8080\nDataSources:\n  default:\n    DriverName: postgres\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-events:
+		if e.Key != "DataSources" {
+			t.Fatalf("unexpected key %s", e.Key)
+		}
+		if v := config.Get("DataSources.default.DriverName", ""); v != "postgres" {
+			t.Fatalf("expect postgres but get %s", v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("change not notified")
+	}
+	select {
+	case e := <-portEvents:
+		t.Fatalf("unchanged key notified: %v", e)
+	default:
+	}
+
+	if err := os.WriteFile(filename, []byte("ServerPort: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-errs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("parse error not reported")
+	}
+	if v := config.Get("DataSources.default.DriverName", ""); v != "postgres" {
+		t.Fatalf("expect previous value kept but get %s", v)
+	}
+}
+
+func TestWatcherZeroInterval(t *testing.T) {
+	watcher := yfig.NewWatcher(nil, func() error { return nil })
+	watcher.Interval = 0
+	if err := watcher.Start(); err != nil {
+		t.Fatal(err)
+	}
+	watcher.Stop()
+}
+
+func TestWatcherNoSpuriousReload(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(filename, []byte("a: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reloads := make(chan struct{}, 10)
+	watcher := yfig.NewWatcher([]string{filename}, func() error {
+		reloads <- struct{}{}
+		return nil
+	})
+	watcher.Interval = 10 * time.Millisecond
+	watcher.Debounce = 0
+	if err := watcher.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Stop()
+
+	select {
+	case <-reloads:
+		t.Fatal("unexpected reload without change")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := os.WriteFile(filename, []byte("a: 22\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-reloads:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expect reload after change")
+	}
+	select {
+	case <-reloads:
+		t.Fatal("unexpected second reload")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,1023 @@
+package yfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// TOML v1.0格式的ValueReader
+// 整数解析为int64，浮点数解析为float64，日期时间保持原文本解析为string（与YamlReader一致）
+type TomlReader struct{}
+
+func NewTomlReader() *TomlReader {
+	return &TomlReader{}
+}
+
+// TOML v1.0格式的ValueLoader
+// map序列化为TOML文档，其他值序列化为TOML值的文本（如"8080"、"[1, 2]"），null值被忽略
+type TomlLoader struct{}
+
+func NewTomlLoader() *TomlLoader {
+	return &TomlLoader{}
+}
+
+func (v *TomlReader) Read(r io.Reader) (*Value, error) {
+	buf := bytes.NewBuffer(nil)
+
+	_, err := io.Copy(buf, r)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := parseToml(buf.String())
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+func (v *TomlLoader) Serialize(o interface{}) (string, error) {
+	o = normalizeValue(o)
+	if m, ok := o.(map[string]interface{}); ok {
+		buf := &strings.Builder{}
+		err := encodeTomlTable(buf, m, nil)
+		return buf.String(), err
+	}
+	return encodeTomlValue(o)
+}
+
+func (v *TomlLoader) Deserialize(value string, result interface{}) error {
+	o, err := parseTomlValueText(value)
+	if err != nil {
+		m, derr := parseToml(value)
+		if derr != nil {
+			return derr
+		}
+		o = m
+	}
+	return assignValue(o, result)
+}
+
+// 将通用的属性值填充到result，result为*interface{}时直接赋值，否则通过json转换
+func assignValue(o interface{}, result interface{}) error {
+	if p, ok := result.(*interface{}); ok {
+		*p = o
+		return nil
+	}
+	b, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, result)
+}
+
+// 将非通用类型（struct、具体类型的slice和map等）通过json转换为通用类型，不修改o
+func normalizeValue(o interface{}) interface{} {
+	switch v := o.(type) {
+	case nil, string, bool, float64, float32, int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64, time.Time:
+		return o
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(v))
+		for k := range v {
+			ret[k] = normalizeValue(v[k])
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(v))
+		for i := range v {
+			ret[i] = normalizeValue(v[i])
+		}
+		return ret
+	}
+	b, err := json.Marshal(o)
+	if err != nil {
+		return o
+	}
+	var ret interface{}
+	if json.Unmarshal(b, &ret) != nil {
+		return o
+	}
+	return ret
+}
+
+type tomlParser struct {
+	src  []rune
+	pos  int
+	line int
+
+	root map[string]interface{}
+	cur  map[string]interface{}
+	// 当前表的路径标识
+	curPath string
+	// 已显式定义的表
+	tables map[string]bool
+	// 通过[[ ]]定义的表数组
+	arrays map[string]bool
+	// 通过带"."的key定义的表，不能再使用[ ]定义
+	dotted map[string]bool
+	// 已赋值的key
+	keys map[string]bool
+}
+
+func parseToml(s string) (map[string]interface{}, error) {
+	p := &tomlParser{
+		src:    []rune(s),
+		line:   1,
+		root:   map[string]interface{}{},
+		tables: map[string]bool{},
+		arrays: map[string]bool{},
+		dotted: map[string]bool{},
+		keys:   map[string]bool{},
+	}
+	p.cur = p.root
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.root, nil
+}
+
+// 解析单独的TOML值文本，如"8080"、"\"abc\""、"[1, 2]"
+func parseTomlValueText(s string) (interface{}, error) {
+	p := &tomlParser{src: []rune(strings.TrimSpace(s)), line: 1}
+	v, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.eof() {
+		return nil, p.errorf("unexpected %q after value", p.peek())
+	}
+	return v, nil
+}
+
+func (p *tomlParser) errorf(format string, o ...interface{}) error {
+	return fmt.Errorf("toml: line %d: %s", p.line, fmt.Sprintf(format, o...))
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *tomlParser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *tomlParser) peekAt(offset int) rune {
+	if p.pos+offset >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos+offset]
+}
+
+func (p *tomlParser) next() rune {
+	c := p.src[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
+
+func (p *tomlParser) hasPrefix(s string) bool {
+	for i, c := range []rune(s) {
+		if p.peekAt(i) != c {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *tomlParser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+func (p *tomlParser) skipComment() {
+	if p.peek() == '#' {
+		for !p.eof() && p.peek() != '\n' {
+			p.pos++
+		}
+	}
+}
+
+// 跳过空白、换行和注释
+func (p *tomlParser) skipBlank() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\r', '\n':
+			p.next()
+		case '#':
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+// 行尾只允许空白和注释
+func (p *tomlParser) expectLineEnd() error {
+	p.skipSpace()
+	p.skipComment()
+	if p.eof() {
+		return nil
+	}
+	if p.peek() == '\r' && p.peekAt(1) == '\n' {
+		p.pos++
+	}
+	if p.peek() != '\n' {
+		return p.errorf("expect new line but get %q", p.peek())
+	}
+	p.next()
+	return nil
+}
+
+func (p *tomlParser) parse() error {
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil
+		}
+		var err error
+		if p.hasPrefix("[[") {
+			err = p.parseArrayTable()
+		} else if p.peek() == '[' {
+			err = p.parseTable()
+		} else {
+			err = p.parseKeyValue(p.cur, p.curPath)
+		}
+		if err != nil {
+			return err
+		}
+		if err := p.expectLineEnd(); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *tomlParser) parseTable() error {
+	p.next()
+	p.skipSpace()
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if p.peek() != ']' {
+		return p.errorf("expect ']' but get %q", p.peek())
+	}
+	p.next()
+
+	m, path, err := p.walkTables(p.root, "", keys)
+	if err != nil {
+		return err
+	}
+	if p.tables[path] || p.dotted[path] {
+		return p.errorf("table %s redefined", strings.Join(keys, "."))
+	}
+	p.tables[path] = true
+	p.cur = m
+	p.curPath = path
+	return nil
+}
+
+func (p *tomlParser) parseArrayTable() error {
+	p.next()
+	p.next()
+	p.skipSpace()
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if !p.hasPrefix("]]") {
+		return p.errorf("expect ']]' but get %q", p.peek())
+	}
+	p.next()
+	p.next()
+
+	parent, path, err := p.walkTables(p.root, "", keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	path = path + "\x00" + last
+	m := map[string]interface{}{}
+	switch v := parent[last].(type) {
+	case nil:
+		if _, ok := parent[last]; ok {
+			return p.errorf("key %s already defined", last)
+		}
+		parent[last] = []interface{}{m}
+		p.arrays[path] = true
+	case []interface{}:
+		if !p.arrays[path] {
+			return p.errorf("key %s already defined as static array", last)
+		}
+		parent[last] = append(v, m)
+	default:
+		return p.errorf("key %s already defined", last)
+	}
+	p.cur = m
+	p.curPath = fmt.Sprintf("%s\x00#%d", path, len(parent[last].([]interface{}))-1)
+	return nil
+}
+
+// 从node开始按keys查找或创建表，进入表数组时使用最后一个元素
+func (p *tomlParser) walkTables(node map[string]interface{}, path string, keys []string) (map[string]interface{}, string, error) {
+	for _, k := range keys {
+		path = path + "\x00" + k
+		switch v := node[k].(type) {
+		case nil:
+			if _, ok := node[k]; ok {
+				return nil, "", p.errorf("key %s already defined", k)
+			}
+			child := map[string]interface{}{}
+			node[k] = child
+			node = child
+		case map[string]interface{}:
+			if p.keys[path] {
+				return nil, "", p.errorf("key %s already defined as value", k)
+			}
+			node = v
+		case []interface{}:
+			if !p.arrays[path] || len(v) == 0 {
+				return nil, "", p.errorf("key %s already defined as static array", k)
+			}
+			node = v[len(v)-1].(map[string]interface{})
+			path = fmt.Sprintf("%s\x00#%d", path, len(v)-1)
+		default:
+			return nil, "", p.errorf("key %s already defined", k)
+		}
+	}
+	return node, path, nil
+}
+
+func (p *tomlParser) parseKeyValue(node map[string]interface{}, path string) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if p.peek() != '=' {
+		return p.errorf("expect '=' but get %q", p.peek())
+	}
+	p.next()
+	p.skipSpace()
+	v, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys[:len(keys)-1] {
+		path = path + "\x00" + k
+		switch child := node[k].(type) {
+		case nil:
+			if _, ok := node[k]; ok {
+				return p.errorf("key %s already defined", k)
+			}
+			m := map[string]interface{}{}
+			node[k] = m
+			node = m
+		case map[string]interface{}:
+			if p.keys[path] || p.tables[path] {
+				return p.errorf("key %s already defined", k)
+			}
+			node = child
+		default:
+			return p.errorf("key %s already defined", k)
+		}
+		if p.dotted != nil {
+			p.dotted[path] = true
+		}
+	}
+	last := keys[len(keys)-1]
+	if _, ok := node[last]; ok {
+		return p.errorf("key %s already defined", last)
+	}
+	node[last] = v
+	if p.keys != nil {
+		p.keys[path+"\x00"+last] = true
+	}
+	return nil
+}
+
+// 解析可能带"."的key
+func (p *tomlParser) parseKey() ([]string, error) {
+	var ret []string
+	for {
+		p.skipSpace()
+		var k string
+		var err error
+		switch c := p.peek(); {
+		case c == '"':
+			p.next()
+			k, err = p.parseBasicString()
+		case c == '\'':
+			p.next()
+			k, err = p.parseLiteralString()
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.peek()) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, p.errorf("invalid key character %q", p.peek())
+			}
+			k = string(p.src[start:p.pos])
+		}
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, k)
+		p.skipSpace()
+		if p.peek() != '.' {
+			return ret, nil
+		}
+		p.next()
+	}
+}
+
+func isBareKeyChar(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' || c == '-'
+}
+
+func (p *tomlParser) parseValue() (interface{}, error) {
+	if p.eof() {
+		return nil, p.errorf("unexpected end of input")
+	}
+	switch c := p.peek(); {
+	case p.hasPrefix(`"""`):
+		p.pos += 3
+		return p.parseMultilineString(true)
+	case p.hasPrefix(`'''`):
+		p.pos += 3
+		return p.parseMultilineString(false)
+	case c == '"':
+		p.next()
+		return p.parseBasicString()
+	case c == '\'':
+		p.next()
+		return p.parseLiteralString()
+	case c == '[':
+		p.next()
+		return p.parseArray()
+	case c == '{':
+		p.next()
+		return p.parseInlineTable()
+	case p.hasPrefix("true") && !isBareKeyChar(p.peekAt(4)):
+		p.pos += 4
+		return true, nil
+	case p.hasPrefix("false") && !isBareKeyChar(p.peekAt(5)):
+		p.pos += 5
+		return false, nil
+	}
+	return p.parseScalar()
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	buf := strings.Builder{}
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.next()
+		switch c {
+		case '"':
+			return buf.String(), nil
+		case '\\':
+			r, err := p.parseEscape()
+			if err != nil {
+				return "", err
+			}
+			buf.WriteRune(r)
+		default:
+			buf.WriteRune(c)
+		}
+	}
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	start := p.pos
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		if p.peek() == '\'' {
+			s := string(p.src[start:p.pos])
+			p.next()
+			return s, nil
+		}
+		p.next()
+	}
+}
+
+// basic为true时处理转义及行尾反斜杠
+func (p *tomlParser) parseMultilineString(basic bool) (string, error) {
+	quote := '\''
+	if basic {
+		quote = '"'
+	}
+	if p.hasPrefix("\r\n") {
+		p.pos++
+	}
+	if p.peek() == '\n' {
+		p.next()
+	}
+	buf := strings.Builder{}
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated multi-line string")
+		}
+		c := p.peek()
+		if c == quote && p.peekAt(1) == quote && p.peekAt(2) == quote {
+			n := 3
+			for p.peekAt(n) == quote {
+				n++
+			}
+			if n > 5 {
+				return "", p.errorf("too many quotes")
+			}
+			for i := 3; i < n; i++ {
+				buf.WriteRune(quote)
+			}
+			p.pos += n
+			return buf.String(), nil
+		}
+		p.next()
+		if basic && c == '\\' {
+			// 行尾反斜杠：去掉之后的空白及换行
+			i := 0
+			for p.peekAt(i) == ' ' || p.peekAt(i) == '\t' {
+				i++
+			}
+			if p.peekAt(i) == '\n' || (p.peekAt(i) == '\r' && p.peekAt(i+1) == '\n') {
+				for !p.eof() && strings.ContainsRune(" \t\r\n", p.peek()) {
+					p.next()
+				}
+				continue
+			}
+			r, err := p.parseEscape()
+			if err != nil {
+				return "", err
+			}
+			buf.WriteRune(r)
+			continue
+		}
+		buf.WriteRune(c)
+	}
+}
+
+func (p *tomlParser) parseEscape() (rune, error) {
+	if p.eof() {
+		return 0, p.errorf("unterminated escape")
+	}
+	c := p.next()
+	switch c {
+	case 'b':
+		return '\b', nil
+	case 't':
+		return '\t', nil
+	case 'n':
+		return '\n', nil
+	case 'f':
+		return '\f', nil
+	case 'r':
+		return '\r', nil
+	case 'e':
+		return 0x1b, nil
+	case '"':
+		return '"', nil
+	case '\\':
+		return '\\', nil
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.pos+n > len(p.src) {
+			return 0, p.errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(string(p.src[p.pos:p.pos+n]), 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return 0, p.errorf("invalid unicode escape")
+		}
+		p.pos += n
+		return rune(code), nil
+	}
+	return 0, p.errorf("invalid escape \\%c", c)
+}
+
+func (p *tomlParser) parseArray() (interface{}, error) {
+	ret := []interface{}{}
+	for {
+		p.skipBlank()
+		if p.peek() == ']' {
+			p.next()
+			return ret, nil
+		}
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, v)
+		p.skipBlank()
+		switch p.peek() {
+		case ',':
+			p.next()
+		case ']':
+			p.next()
+			return ret, nil
+		default:
+			return nil, p.errorf("expect ',' or ']' in array but get %q", p.peek())
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (interface{}, error) {
+	ret := map[string]interface{}{}
+	keys := p.keys
+	tables := p.tables
+	// 内联表中的key单独检查重复
+	p.keys = map[string]bool{}
+	p.tables = map[string]bool{}
+	defer func() {
+		p.keys = keys
+		p.tables = tables
+	}()
+
+	p.skipSpace()
+	if p.peek() == '}' {
+		p.next()
+		return ret, nil
+	}
+	for {
+		p.skipSpace()
+		if err := p.parseKeyValue(ret, ""); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.next()
+		case '}':
+			p.next()
+			return ret, nil
+		default:
+			return nil, p.errorf("expect ',' or '}' in inline table but get %q", p.peek())
+		}
+	}
+}
+
+var (
+	tomlDateTimeLayouts = []string{
+		"2006-01-02T15:04:05.999999999Z07:00",
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02",
+	}
+	tomlTimeLayout = "15:04:05.999999999"
+)
+
+// 解析数字、日期时间等无引号的值
+func (p *tomlParser) parseScalar() (interface{}, error) {
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(" \t\r\n,]}#", p.peek()) {
+		p.pos++
+	}
+	// 日期与时间之间允许使用空格分隔
+	if p.pos-start == 10 && p.peek() == ' ' && isDigit(p.peekAt(1)) && isDigit(p.peekAt(2)) && p.peekAt(3) == ':' {
+		p.pos++
+		for !p.eof() && !strings.ContainsRune(" \t\r\n,]}#", p.peek()) {
+			p.pos++
+		}
+	}
+	s := string(p.src[start:p.pos])
+	if s == "" {
+		return nil, p.errorf("expect value but get %q", p.peek())
+	}
+
+	switch s {
+	case "inf", "+inf":
+		return math.Inf(1), nil
+	case "-inf":
+		return math.Inf(-1), nil
+	case "nan", "+nan", "-nan":
+		return math.NaN(), nil
+	}
+
+	if len(s) >= 8 && (s[2] == ':' || (len(s) >= 10 && s[4] == '-')) {
+		if _, err := time.Parse(tomlTimeLayout, s); err == nil {
+			return s, nil
+		}
+		norm := strings.ToUpper(strings.Replace(s, " ", "T", 1))
+		for _, layout := range tomlDateTimeLayouts {
+			if _, err := time.Parse(layout, norm); err == nil {
+				return s, nil
+			}
+		}
+		return nil, p.errorf("invalid datetime %s", s)
+	}
+
+	if err := checkUnderscore(s); err != nil {
+		return nil, p.errorf("invalid number %s", s)
+	}
+	num := strings.ReplaceAll(s, "_", "")
+	if len(num) > 2 && num[0] == '0' {
+		base := 0
+		switch num[1] {
+		case 'x':
+			base = 16
+		case 'o':
+			base = 8
+		case 'b':
+			base = 2
+		}
+		if base != 0 {
+			i, err := strconv.ParseInt(num[2:], base, 64)
+			if err != nil {
+				return nil, p.errorf("invalid integer %s", s)
+			}
+			return i, nil
+		}
+	}
+	digits := strings.TrimLeft(num, "+-")
+	if strings.ContainsAny(num, ".eE") {
+		if strings.HasPrefix(digits, ".") || strings.Contains(num, ".e") || strings.Contains(num, ".E") ||
+			strings.HasSuffix(num, ".") || (len(digits) > 1 && digits[0] == '0' && digits[1] != '.' && digits[1] != 'e' && digits[1] != 'E') {
+			return nil, p.errorf("invalid float %s", s)
+		}
+		f, err := strconv.ParseFloat(num, 64)
+		if err != nil {
+			return nil, p.errorf("invalid float %s", s)
+		}
+		return f, nil
+	}
+	if len(digits) > 1 && digits[0] == '0' {
+		return nil, p.errorf("leading zeros are not allowed: %s", s)
+	}
+	i, err := strconv.ParseInt(num, 10, 64)
+	if err != nil {
+		return nil, p.errorf("invalid value %s", s)
+	}
+	return i, nil
+}
+
+func isDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+// 下划线两侧必须为数字
+func checkUnderscore(s string) error {
+	for i := 0; i < len(s); i++ {
+		if s[i] != '_' {
+			continue
+		}
+		if i == 0 || i == len(s)-1 || !isHexDigit(s[i-1]) || !isHexDigit(s[i+1]) {
+			return fmt.Errorf("invalid underscore")
+		}
+	}
+	return nil
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// 先输出当前表的值，再输出子表和表数组
+func encodeTomlTable(buf *strings.Builder, m map[string]interface{}, path []string) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := m[k]
+		if v == nil || isTomlTable(v) || isTomlTableArray(v) {
+			continue
+		}
+		s, err := encodeTomlValue(v)
+		if err != nil {
+			return fmt.Errorf("key %s: %s", k, err.Error())
+		}
+		buf.WriteString(encodeTomlKey(k))
+		buf.WriteString(" = ")
+		buf.WriteString(s)
+		buf.WriteString("\n")
+	}
+	for _, k := range keys {
+		sub := append(append([]string(nil), path...), k)
+		switch v := m[k].(type) {
+		case map[string]interface{}:
+			if needTomlHeader(v) {
+				writeTomlHeader(buf, "[", sub, "]")
+			}
+			if err := encodeTomlTable(buf, v, sub); err != nil {
+				return err
+			}
+		case []interface{}:
+			if !isTomlTableArray(v) {
+				continue
+			}
+			for _, e := range v {
+				writeTomlHeader(buf, "[[", sub, "]]")
+				if err := encodeTomlTable(buf, e.(map[string]interface{}), sub); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func writeTomlHeader(buf *strings.Builder, open string, path []string, end string) {
+	if buf.Len() > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString(open)
+	for i, k := range path {
+		if i > 0 {
+			buf.WriteString(".")
+		}
+		buf.WriteString(encodeTomlKey(k))
+	}
+	buf.WriteString(end)
+	buf.WriteString("\n")
+}
+
+// 表中包含值或为空表时需要输出表头，只包含子表时可以省略
+func needTomlHeader(m map[string]interface{}) bool {
+	if len(m) == 0 {
+		return true
+	}
+	for _, v := range m {
+		if v != nil && !isTomlTable(v) && !isTomlTableArray(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func isTomlTable(v interface{}) bool {
+	_, ok := v.(map[string]interface{})
+	return ok
+}
+
+func isTomlTableArray(v interface{}) bool {
+	a, ok := v.([]interface{})
+	if !ok || len(a) == 0 {
+		return false
+	}
+	for _, e := range a {
+		if !isTomlTable(e) {
+			return false
+		}
+	}
+	return true
+}
+
+func encodeTomlKey(k string) string {
+	if k == "" {
+		return `""`
+	}
+	for _, c := range k {
+		if !isBareKeyChar(c) {
+			return encodeTomlString(k)
+		}
+	}
+	return k
+}
+
+func encodeTomlString(s string) string {
+	buf := strings.Builder{}
+	buf.WriteByte('"')
+	for _, c := range s {
+		switch c {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				buf.WriteString(fmt.Sprintf(`\u%04X`, c))
+			} else {
+				buf.WriteRune(c)
+			}
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+func encodeTomlValue(v interface{}) (string, error) {
+	switch o := v.(type) {
+	case string:
+		return encodeTomlString(o), nil
+	case bool:
+		return strconv.FormatBool(o), nil
+	case float64:
+		return encodeTomlFloat(o), nil
+	case float32:
+		return encodeTomlFloat(float64(o)), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", o), nil
+	case time.Time:
+		return o.Format(time.RFC3339Nano), nil
+	case []interface{}:
+		buf := strings.Builder{}
+		buf.WriteString("[")
+		n := 0
+		for _, e := range o {
+			if e == nil {
+				continue
+			}
+			s, err := encodeTomlValue(e)
+			if err != nil {
+				return "", err
+			}
+			if n > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(s)
+			n++
+		}
+		buf.WriteString("]")
+		return buf.String(), nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(o))
+		for k := range o {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf := strings.Builder{}
+		buf.WriteString("{")
+		n := 0
+		for _, k := range keys {
+			if o[k] == nil {
+				continue
+			}
+			s, err := encodeTomlValue(o[k])
+			if err != nil {
+				return "", err
+			}
+			if n > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(encodeTomlKey(k))
+			buf.WriteString(" = ")
+			buf.WriteString(s)
+			n++
+		}
+		buf.WriteString("}")
+		return buf.String(), nil
+	case nil:
+		return "", fmt.Errorf("toml does not support null value")
+	}
+	n := normalizeValue(v)
+	if reflect.TypeOf(n) != reflect.TypeOf(v) {
+		return encodeTomlValue(n)
+	}
+	return "", fmt.Errorf("toml: unsupported type %T", v)
+}
+
+// 整数值的浮点数输出为整数（YamlReader、JsonReader读取的数字均为float64）
+func encodeTomlFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case f == math.Trunc(f) && math.Abs(f) < 1e15:
+		return strconv.FormatInt(int64(f), 10)
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return s
+}
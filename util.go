@@ -3,10 +3,10 @@ package yfig
 import (
 	"errors"
 	"fmt"
-	"github.com/ydx1011/reflection"
 	"os"
-	"reflect"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -14,21 +14,36 @@ const (
 	TagName       = "fig"
 )
 
-func LoadJsonFile(filename string) (Properties, error) {
-	return LoadFile(filename, NewJsonReader(), NewJsonLoader())
+func LoadJsonFile(filename string, opts ...Opt) (Properties, error) {
+	return LoadFile(filename, NewJsonReader(), NewJsonLoader(), opts...)
 }
 
-func LoadYamlFile(filename string) (Properties, error) {
-	return LoadFile(filename, NewYamlReader(), NewYamlLoader())
+func LoadYamlFile(filename string, opts ...Opt) (Properties, error) {
+	return LoadFile(filename, NewYamlReader(), NewYamlLoader(), opts...)
 }
 
-func LoadFile(filename string, reader ValueReader, loader ValueLoader) (Properties, error) {
+func LoadTomlFile(filename string, opts ...Opt) (Properties, error) {
+	return LoadFile(filename, NewTomlReader(), NewTomlLoader(), opts...)
+}
+
+func LoadPropertiesFile(filename string, opts ...Opt) (Properties, error) {
+	return LoadFile(filename, NewPropertiesReader(), NewYamlLoader(), opts...)
+}
+
+func LoadIniFile(filename string, opts ...Opt) (Properties, error) {
+	return LoadFile(filename, NewIniReader(), NewYamlLoader(), opts...)
+}
+
+func LoadFile(filename string, reader ValueReader, loader ValueLoader, opts ...Opt) (Properties, error) {
 	f, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
-	prop := New()
+	prop := New(opts...)
+	if prop == nil {
+		return nil, errors.New("create properties failed")
+	}
 	prop.SetValueReader(reader)
 	prop.SetValueLoader(loader)
 	err = prop.ReadValue(f)
@@ -54,6 +69,18 @@ func FillEx(prop Properties, result interface{}, withField bool) error {
 	return FillExWithTagName(prop, result, withField, TagPrefixName, TagName)
 }
 
+// 严格模式填充，属性不存在（struct指针除外）或类型不匹配时返回Errors，每个元素为*KeyError
+// param: prop 属性
+// param: result 填充的struct
+// param: checkUnknown 是否检查figPx前缀下没有被字段使用的属性（通常为拼写错误的配置）
+// result: result如果不为struct的指针返回错误，填充或校验失败返回Errors
+func FillStrict(prop Properties, result interface{}, checkUnknown bool) error {
+	f := newFiller(false, []string{TagPrefixName}, []string{TagName}, false)
+	f.strict = true
+	f.unknown = checkUnknown
+	return f.fill(prop, result)
+}
+
 // param: prop 属性
 // param: result 填充的struct
 // param: withField 是否根据field name填充
@@ -61,52 +88,7 @@ func FillEx(prop Properties, result interface{}, withField bool) error {
 // param: tagName tag名
 // result: result如果不为struct的指针返回错误，填充时异常返回错误
 func FillExWithTagName(prop Properties, result interface{}, withField bool, tagPxName, tagName string) error {
-	t := reflect.TypeOf(result)
-	v := reflect.ValueOf(result)
-
-	if t.Kind() != reflect.Ptr {
-		return errors.New("result must be ptr")
-	}
-	t = t.Elem()
-	v = v.Elem()
-
-	if t.Kind() != reflect.Struct {
-		return errors.New("result must be struct ptr")
-	}
-
-	prefix := ""
-	for i := 0; i < v.NumField(); i++ {
-		field := t.Field(i)
-		tag := field.Tag.Get(tagPxName)
-		if tag != "" {
-			prefix = tag
-			continue
-		}
-		tag = field.Tag.Get(tagName)
-		if tag != "" {
-			if tag == "-" {
-				continue
-			}
-		} else if withField {
-			tag = field.Name
-		}
-
-		if tag != "" {
-			if prefix != "" {
-				tag = prefix + "." + tag
-			}
-			c := reflect.New(field.Type).Interface()
-			err := prop.GetValue(tag, c)
-			if err != nil {
-				logf(err.Error())
-			}
-			fieldValue := v.Field(i)
-			if fieldValue.CanSet() {
-				fieldValue.Set(reflect.ValueOf(c).Elem())
-			}
-		}
-	}
-	return nil
+	return newFiller(withField, []string{tagPxName}, []string{tagName}, false).fill(prop, result)
 }
 
 // param: prop 属性
@@ -119,80 +101,7 @@ func FillExWithTagNames(prop Properties, result interface{}, withField bool, tag
 	if len(tagPxNames) != len(tagNames) {
 		return fmt.Errorf("tagPxNames lens not the same with tagNames")
 	}
-	t := reflect.TypeOf(result)
-	v := reflect.ValueOf(result)
-
-	if t.Kind() != reflect.Ptr {
-		return errors.New("result must be ptr")
-	}
-	t = t.Elem()
-	v = v.Elem()
-
-	if t.Kind() != reflect.Struct {
-		return errors.New("result must be struct ptr")
-	}
-
-	errs := Errors{}
-	prefix := make([]string, len(tagPxNames))
-	for i := 0; i < v.NumField(); i++ {
-		field := t.Field(i)
-		for tagIndex := range tagPxNames {
-			tagPxName := tagPxNames[tagIndex]
-			tagName := tagNames[tagIndex]
-			tagValue := field.Tag.Get(tagPxName)
-			if tagValue != "" {
-				prefix[tagIndex] = tagValue
-				continue
-			}
-			tagValue = field.Tag.Get(tagName)
-			if tagValue != "" {
-				if tagValue == "-" {
-					break
-				}
-			} else if tagIndex < len(tagPxNames)-1 {
-				continue
-			} else if withField {
-				tagValue = field.Name
-			}
-
-			if tagValue != "" {
-				tags := strings.Split(tagValue, ",")
-				defaultStr := ""
-				if len(tags) > 1 {
-					tagValue = tags[0]
-					if index := strings.Index(tags[1], "default="); index != -1 {
-						defaultStr = tags[1][len("default="):]
-					}
-				}
-				if prefix[tagIndex] != "" {
-					tagValue = prefix[tagIndex] + "." + tagValue
-				}
-				c := reflect.New(field.Type).Interface()
-				fieldValue := v.Field(i)
-				if defaultStr == "" {
-					err := prop.GetValue(tagValue, c)
-					if err != nil {
-						logf(err.Error())
-						errs.AddError(err)
-						break
-					}
-					if fieldValue.CanSet() {
-						fieldValue.Set(reflect.ValueOf(c).Elem())
-					}
-				} else {
-					value := prop.Get(tagValue, defaultStr)
-					if ok := reflection.SetValue(fieldValue, reflect.ValueOf(value)); !ok {
-						errs.AddError(errors.New("Not assigned. "))
-					}
-				}
-				break
-			}
-		}
-	}
-	if errs.Empty() {
-		return nil
-	}
-	return errs
+	return newFiller(withField, tagPxNames, tagNames, true).fill(prop, result)
 }
 
 type Errors []error
@@ -216,3 +125,300 @@ func (es Errors) Error() string {
 	}
 	return buf.String()
 }
+
+func getRawValue(prop Properties, key string) (interface{}, error) {
+	var ret interface{}
+	err := prop.GetValue(key, &ret)
+	return ret, err
+}
+
+// param: prop 属性
+// return: 获取string属性值的方法，属性不存在或转换失败时返回默认值
+func GetString(prop Properties) func(key string, defaultValue string) string {
+	get := GetStringE(prop)
+	return func(key string, defaultValue string) string {
+		if v, err := get(key); err == nil {
+			return v
+		}
+		return defaultValue
+	}
+}
+
+// param: prop 属性
+// return: 获取string属性值的方法，属性不存在或转换失败时返回错误
+func GetStringE(prop Properties) func(key string) (string, error) {
+	return func(key string) (string, error) {
+		v, err := getRawValue(prop, key)
+		if err != nil {
+			return "", err
+		}
+		return toString(v)
+	}
+}
+
+// param: prop 属性
+// return: 获取bool属性值的方法，属性不存在或转换失败时返回默认值
+func GetBool(prop Properties) func(key string, defaultValue bool) bool {
+	get := GetBoolE(prop)
+	return func(key string, defaultValue bool) bool {
+		if v, err := get(key); err == nil {
+			return v
+		}
+		return defaultValue
+	}
+}
+
+// param: prop 属性
+// return: 获取bool属性值的方法，属性不存在或转换失败时返回错误
+func GetBoolE(prop Properties) func(key string) (bool, error) {
+	return func(key string) (bool, error) {
+		v, err := getRawValue(prop, key)
+		if err != nil {
+			return false, err
+		}
+		return toBool(v)
+	}
+}
+
+// param: prop 属性
+// return: 获取int属性值的方法，属性不存在或转换失败时返回默认值
+func GetInt(prop Properties) func(key string, defaultValue int) int {
+	get := GetIntE(prop)
+	return func(key string, defaultValue int) int {
+		if v, err := get(key); err == nil {
+			return v
+		}
+		return defaultValue
+	}
+}
+
+// param: prop 属性
+// return: 获取int属性值的方法，属性不存在或转换失败时返回错误
+func GetIntE(prop Properties) func(key string) (int, error) {
+	return func(key string) (int, error) {
+		v, err := getRawValue(prop, key)
+		if err != nil {
+			return 0, err
+		}
+		i, err := toInt64(v, strconv.IntSize)
+		return int(i), err
+	}
+}
+
+// param: prop 属性
+// return: 获取uint属性值的方法，属性不存在或转换失败时返回默认值
+func GetUint(prop Properties) func(key string, defaultValue uint) uint {
+	get := GetUintE(prop)
+	return func(key string, defaultValue uint) uint {
+		if v, err := get(key); err == nil {
+			return v
+		}
+		return defaultValue
+	}
+}
+
+// param: prop 属性
+// return: 获取uint属性值的方法，属性不存在或转换失败时返回错误
+func GetUintE(prop Properties) func(key string) (uint, error) {
+	return func(key string) (uint, error) {
+		v, err := getRawValue(prop, key)
+		if err != nil {
+			return 0, err
+		}
+		u, err := toUint64(v, strconv.IntSize)
+		return uint(u), err
+	}
+}
+
+// param: prop 属性
+// return: 获取int64属性值的方法，属性不存在或转换失败时返回默认值
+func GetInt64(prop Properties) func(key string, defaultValue int64) int64 {
+	get := GetInt64E(prop)
+	return func(key string, defaultValue int64) int64 {
+		if v, err := get(key); err == nil {
+			return v
+		}
+		return defaultValue
+	}
+}
+
+// param: prop 属性
+// return: 获取int64属性值的方法，属性不存在或转换失败时返回错误
+func GetInt64E(prop Properties) func(key string) (int64, error) {
+	return func(key string) (int64, error) {
+		v, err := getRawValue(prop, key)
+		if err != nil {
+			return 0, err
+		}
+		return toInt64(v, 64)
+	}
+}
+
+// param: prop 属性
+// return: 获取uint64属性值的方法，属性不存在或转换失败时返回默认值
+func GetUint64(prop Properties) func(key string, defaultValue uint64) uint64 {
+	get := GetUint64E(prop)
+	return func(key string, defaultValue uint64) uint64 {
+		if v, err := get(key); err == nil {
+			return v
+		}
+		return defaultValue
+	}
+}
+
+// param: prop 属性
+// return: 获取uint64属性值的方法，属性不存在或转换失败时返回错误
+func GetUint64E(prop Properties) func(key string) (uint64, error) {
+	return func(key string) (uint64, error) {
+		v, err := getRawValue(prop, key)
+		if err != nil {
+			return 0, err
+		}
+		return toUint64(v, 64)
+	}
+}
+
+// param: prop 属性
+// return: 获取float32属性值的方法，属性不存在或转换失败时返回默认值
+func GetFloat32(prop Properties) func(key string, defaultValue float32) float32 {
+	get := GetFloat32E(prop)
+	return func(key string, defaultValue float32) float32 {
+		if v, err := get(key); err == nil {
+			return v
+		}
+		return defaultValue
+	}
+}
+
+// param: prop 属性
+// return: 获取float32属性值的方法，属性不存在或转换失败时返回错误
+func GetFloat32E(prop Properties) func(key string) (float32, error) {
+	return func(key string) (float32, error) {
+		v, err := getRawValue(prop, key)
+		if err != nil {
+			return 0, err
+		}
+		f, err := toFloat64(v, 32)
+		return float32(f), err
+	}
+}
+
+// param: prop 属性
+// return: 获取float64属性值的方法，属性不存在或转换失败时返回默认值
+func GetFloat64(prop Properties) func(key string, defaultValue float64) float64 {
+	get := GetFloat64E(prop)
+	return func(key string, defaultValue float64) float64 {
+		if v, err := get(key); err == nil {
+			return v
+		}
+		return defaultValue
+	}
+}
+
+// param: prop 属性
+// return: 获取float64属性值的方法，属性不存在或转换失败时返回错误
+func GetFloat64E(prop Properties) func(key string) (float64, error) {
+	return func(key string) (float64, error) {
+		v, err := getRawValue(prop, key)
+		if err != nil {
+			return 0, err
+		}
+		return toFloat64(v, 64)
+	}
+}
+
+// param: prop 属性
+// return: 获取time.Duration属性值的方法，属性不存在或转换失败时返回默认值
+func GetDuration(prop Properties) func(key string, defaultValue time.Duration) time.Duration {
+	get := GetDurationE(prop)
+	return func(key string, defaultValue time.Duration) time.Duration {
+		if v, err := get(key); err == nil {
+			return v
+		}
+		return defaultValue
+	}
+}
+
+// param: prop 属性
+// return: 获取time.Duration属性值的方法，属性不存在或转换失败时返回错误
+func GetDurationE(prop Properties) func(key string) (time.Duration, error) {
+	return func(key string) (time.Duration, error) {
+		v, err := getRawValue(prop, key)
+		if err != nil {
+			return 0, err
+		}
+		return toDuration(v)
+	}
+}
+
+// param: prop 属性
+// return: 获取time.Time属性值的方法，属性不存在或转换失败时返回默认值
+func GetTime(prop Properties) func(key string, defaultValue time.Time) time.Time {
+	get := GetTimeE(prop)
+	return func(key string, defaultValue time.Time) time.Time {
+		if v, err := get(key); err == nil {
+			return v
+		}
+		return defaultValue
+	}
+}
+
+// param: prop 属性
+// return: 获取time.Time属性值的方法，属性不存在或转换失败时返回错误
+func GetTimeE(prop Properties) func(key string) (time.Time, error) {
+	return func(key string) (time.Time, error) {
+		v, err := getRawValue(prop, key)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return toTime(v)
+	}
+}
+
+// param: prop 属性
+// return: 获取[]string属性值的方法，属性不存在或转换失败时返回默认值
+func GetStringSlice(prop Properties) func(key string, defaultValue []string) []string {
+	get := GetStringSliceE(prop)
+	return func(key string, defaultValue []string) []string {
+		if v, err := get(key); err == nil {
+			return v
+		}
+		return defaultValue
+	}
+}
+
+// param: prop 属性
+// return: 获取[]string属性值的方法，属性不存在或转换失败时返回错误
+func GetStringSliceE(prop Properties) func(key string) ([]string, error) {
+	return func(key string) ([]string, error) {
+		v, err := getRawValue(prop, key)
+		if err != nil {
+			return nil, err
+		}
+		return toStringSlice(v)
+	}
+}
+
+// param: prop 属性
+// return: 获取map[string]interface{}属性值的方法，属性不存在或转换失败时返回默认值
+func GetStringMap(prop Properties) func(key string, defaultValue map[string]interface{}) map[string]interface{} {
+	get := GetStringMapE(prop)
+	return func(key string, defaultValue map[string]interface{}) map[string]interface{} {
+		if v, err := get(key); err == nil {
+			return v
+		}
+		return defaultValue
+	}
+}
+
+// param: prop 属性
+// return: 获取map[string]interface{}属性值的方法，属性不存在或转换失败时返回错误
+func GetStringMapE(prop Properties) func(key string) (map[string]interface{}, error) {
+	return func(key string) (map[string]interface{}, error) {
+		v, err := getRawValue(prop, key)
+		if err != nil {
+			return nil, err
+		}
+		return toStringMap(v)
+	}
+}
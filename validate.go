@@ -0,0 +1,253 @@
+package yfig
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const ValidateTagName = "validate"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// 字段校验失败的信息
+type FieldError struct {
+	// 字段名
+	Field string
+	// 属性名
+	Key string
+	// 校验规则，如"max=10"
+	Rule string
+	// 字段值
+	Value interface{}
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	if e.Key != "" {
+		return fmt.Sprintf("field %s (key: %s) rule %s failed: %s", e.Field, e.Key, e.Rule, e.Err.Error())
+	}
+	return fmt.Sprintf("field %s rule %s failed: %s", e.Field, e.Rule, e.Err.Error())
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// 根据validate tag校验struct（包括嵌套的struct），Fill系列方法在填充后会自动校验
+// 支持的规则（多个规则以","分隔，规则内容中的逗号使用"\,"）：
+// required: 值不能为零值
+// min=N、max=N: 数字比较大小，string、slice、map比较长度，time.Duration字段可使用"1s"格式
+// oneof=a b c: 值必须为其中之一
+// regexp=pattern: string必须匹配正则表达式
+// url: string必须为带scheme和host的url
+// hostport: string必须为host:port格式
+// duration: string必须为time.ParseDuration支持的格式
+// oneof、regexp、url、hostport、duration在值为空字符串时不校验
+// param: result struct指针
+// return: 校验失败时返回Errors，每个元素为*FieldError
+func Validate(result interface{}) error {
+	v, err := structPtrValue(result)
+	if err != nil {
+		return err
+	}
+	errs := newFiller(false, []string{TagPrefixName}, []string{TagName}, false).validateStruct(v, "", "")
+	if errs.Empty() {
+		return nil
+	}
+	return errs
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// param: v struct
+// param: keys 字段序号对应的属性名
+// param: secrets 字段序号对应的字段是否为secret，FieldError中的值使用Redacted代替
+// param: fieldPath 外层字段名，嵌套struct的字段名为"Outer.Inner"
+func validateFields(v reflect.Value, keys map[int]string, secrets map[int]bool, fieldPath string) error {
+	t := v.Type()
+	errs := Errors{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get(ValidateTagName)
+		if tag == "" || tag == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		for _, rule := range splitTagOptions(tag) {
+			rule = strings.TrimSpace(rule)
+			if rule == "" {
+				continue
+			}
+			if err := checkRule(fv, rule); err != nil {
+				var value interface{}
+				if secrets[i] {
+					value = Redacted
+					err = fmt.Errorf("value %s is invalid", Redacted)
+				} else if fv.CanInterface() {
+					value = fv.Interface()
+				}
+				errs.AddError(&FieldError{
+					Field: joinKey(fieldPath, field.Name),
+					Key:   keys[i],
+					Rule:  rule,
+					Value: value,
+					Err:   err,
+				})
+			}
+		}
+	}
+	if errs.Empty() {
+		return nil
+	}
+	return errs
+}
+
+func checkRule(v reflect.Value, rule string) error {
+	name, arg := rule, ""
+	if i := strings.Index(rule, "="); i != -1 {
+		name, arg = rule[:i], rule[i+1:]
+	}
+	for v.Kind() == reflect.Ptr && name != "required" {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch name {
+	case "required":
+		if isEmptyValue(v) {
+			return errors.New("value is required")
+		}
+		return nil
+	case "min", "max":
+		return checkRange(v, name, arg)
+	}
+
+	if v.Kind() == reflect.String && v.String() == "" {
+		return nil
+	}
+	switch name {
+	case "oneof":
+		s := fmt.Sprintf("%v", v)
+		for _, o := range strings.Fields(arg) {
+			if o == s {
+				return nil
+			}
+		}
+		return fmt.Errorf("value %s is not one of [%s]", s, arg)
+	case "regexp":
+		if v.Kind() != reflect.String {
+			return fmt.Errorf("rule regexp only supports string")
+		}
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return fmt.Errorf("invalid regexp: %s", err.Error())
+		}
+		if !re.MatchString(v.String()) {
+			return fmt.Errorf("value %s does not match %s", v.String(), arg)
+		}
+		return nil
+	case "url":
+		if v.Kind() != reflect.String {
+			return fmt.Errorf("rule url only supports string")
+		}
+		u, err := url.Parse(v.String())
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("value %s is not a valid url", v.String())
+		}
+		return nil
+	case "hostport":
+		if v.Kind() != reflect.String {
+			return fmt.Errorf("rule hostport only supports string")
+		}
+		_, port, err := net.SplitHostPort(v.String())
+		if err != nil {
+			return fmt.Errorf("value %s is not a valid host:port", v.String())
+		}
+		if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+			return fmt.Errorf("value %s has invalid port", v.String())
+		}
+		return nil
+	case "duration":
+		if v.Type() == durationType {
+			return nil
+		}
+		if v.Kind() != reflect.String {
+			return fmt.Errorf("rule duration only supports string")
+		}
+		if _, err := time.ParseDuration(v.String()); err != nil {
+			return fmt.Errorf("value %s is not a valid duration", v.String())
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown rule %s", name)
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return v.Len() == 0
+	case reflect.Invalid:
+		return true
+	}
+	return v.IsZero()
+}
+
+func checkRange(v reflect.Value, name, arg string) error {
+	var n, limit float64
+	if v.Type() == durationType {
+		d, err := toDuration(arg)
+		if err != nil {
+			return fmt.Errorf("invalid %s argument %s", name, arg)
+		}
+		n, limit = float64(v.Int()), float64(d)
+	} else {
+		f, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s argument %s", name, arg)
+		}
+		limit = f
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n = float64(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			n = float64(v.Uint())
+		case reflect.Float32, reflect.Float64:
+			n = v.Float()
+		case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+			n = float64(v.Len())
+			if v.Kind() == reflect.String {
+				n = float64(len([]rune(v.String())))
+			}
+			if name == "min" && n < limit {
+				return fmt.Errorf("length %v is less than %s", n, arg)
+			}
+			if name == "max" && n > limit {
+				return fmt.Errorf("length %v is greater than %s", n, arg)
+			}
+			return nil
+		default:
+			return fmt.Errorf("rule %s does not support %s", name, v.Type().String())
+		}
+	}
+	if name == "min" && n < limit {
+		return fmt.Errorf("value %v is less than %s", v, arg)
+	}
+	if name == "max" && n > limit {
+		return fmt.Errorf("value %v is greater than %s", v, arg)
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+package yfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+)
+
+type ChangeEvent struct {
+	// 订阅的属性前缀
+	Key string
+	// 变更前的值，不存在时为nil
+	Old interface{}
+	// 变更后的值，不存在时为nil
+	New interface{}
+}
+
+type ChangeFunc func(e ChangeEvent)
+
+type subscriber struct {
+	prefix string
+	fn     ChangeFunc
+}
+
+// 订阅属性变更，属性值被替换（ReadValue、重新加载等）且prefix下的内容发生变化时调用fn
+// param: prefix 属性前缀，为空时订阅全部属性
+// param: fn 回调方法，在替换属性值的goroutine中调用
+// return: 取消订阅的方法
+func (ctx *DefaultProperties) OnChange(prefix string, fn ChangeFunc) func() {
+	sub := &subscriber{prefix: prefix, fn: fn}
+	ctx.subsLock.Lock()
+	ctx.subs = append(ctx.subs, sub)
+	ctx.subsLock.Unlock()
+
+	return func() {
+		ctx.subsLock.Lock()
+		defer ctx.subsLock.Unlock()
+		for i := range ctx.subs {
+			if ctx.subs[i] == sub {
+				ctx.subs = append(ctx.subs[:i], ctx.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (ctx *DefaultProperties) notify(old, new *Value) {
+	ctx.subsLock.Lock()
+	subs := append([]*subscriber(nil), ctx.subs...)
+	ctx.subsLock.Unlock()
+
+	for _, sub := range subs {
+		o, _ := lookupValue(old, sub.prefix)
+		n, _ := lookupValue(new, sub.prefix)
+		if !reflect.DeepEqual(o, n) {
+			sub.fn(ChangeEvent{Key: sub.prefix, Old: copyValue(o), New: copyValue(n)})
+		}
+	}
+}
+
+// 按属性路径查找属性值，路径格式见parsePath
+func lookupValue(v *Value, key string) (interface{}, bool) {
+	ret, err := lookupPath(v, key)
+	return ret, err == nil
+}
+
+const (
+	DefaultWatchInterval = 2 * time.Second
+	DefaultWatchDebounce = 100 * time.Millisecond
+)
+
+// 监听文件变化并重新加载属性，默认使用轮询，Linux下可启用inotify
+type Watcher struct {
+	// 轮询间隔，小于等于0时使用DefaultWatchInterval
+	Interval time.Duration
+	// 为true时在支持的系统上使用inotify即时感知变化，轮询仍作为兜底
+	UseNotify bool
+	// 感知到变化后等待文件稳定的时间，期间的再次变化会重新计时，避免读取写入中的文件
+	Debounce time.Duration
+	// 重新加载失败时调用，此时保留原有属性值
+	OnError func(err error)
+
+	files  []string
+	reload func() error
+	stats  map[string]fileStat
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+	lock sync.Mutex
+}
+
+type fileStat struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// 监听filename，变化时使用prop.ReadValue重新读取
+func NewFileWatcher(prop Properties, filename string) *Watcher {
+	return NewWatcher([]string{filename}, func() error {
+		f, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return prop.ReadValue(f)
+	})
+}
+
+// 监听prop中全部FileSource，变化时重新合并所有数据源
+func NewLayeredWatcher(prop *LayeredProperties) *Watcher {
+	var files []string
+	for _, s := range prop.Sources() {
+		if fs, ok := s.(*FileSource); ok {
+			files = append(files, fs.Filename)
+		}
+	}
+	return NewWatcher(files, prop.Load)
+}
+
+// param: files 监听的文件
+// param: reload 文件变化时调用的重新加载方法
+func NewWatcher(files []string, reload func() error) *Watcher {
+	ret := &Watcher{
+		Interval: DefaultWatchInterval,
+		Debounce: DefaultWatchDebounce,
+		OnError: func(err error) {
+			logf("reload failed: %s\n", err.Error())
+		},
+		reload: reload,
+		stats:  map[string]fileStat{},
+	}
+	for _, f := range files {
+		if abs, err := filepath.Abs(f); err == nil {
+			f = abs
+		}
+		ret.files = append(ret.files, f)
+	}
+	return ret
+}
+
+func (w *Watcher) Start() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.stop != nil {
+		return nil
+	}
+	for _, f := range w.files {
+		w.stats[f] = statFile(f)
+	}
+
+	var events <-chan struct{}
+	var closer func()
+	if w.UseNotify {
+		ch, c, err := notifyFiles(w.files)
+		if err != nil {
+			return err
+		}
+		events, closer = ch, c
+	}
+
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	stop := make(chan struct{})
+	w.stop = stop
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		if closer != nil {
+			defer closer()
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		timer := time.NewTimer(w.Debounce)
+		defer timer.Stop()
+		// 停止计时器并清空C，否则之前已触发的时间会导致多余的重新加载
+		stopTimer := func() {
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		}
+		resetTimer := func() {
+			stopTimer()
+			timer.Reset(w.Debounce)
+		}
+		stopTimer()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if w.changed() {
+					resetTimer()
+				}
+			case <-events:
+				w.changed()
+				resetTimer()
+			case <-timer.C:
+				w.changed()
+				if err := w.reload(); err != nil && w.OnError != nil {
+					w.OnError(err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (w *Watcher) Stop() {
+	w.lock.Lock()
+	stop := w.stop
+	w.stop = nil
+	w.lock.Unlock()
+
+	if stop != nil {
+		close(stop)
+		w.wg.Wait()
+	}
+}
+
+// 更新文件状态，返回是否有文件发生变化
+func (w *Watcher) changed() bool {
+	ret := false
+	for _, f := range w.files {
+		st := statFile(f)
+		if st != w.stats[f] {
+			w.stats[f] = st
+			ret = true
+		}
+	}
+	return ret
+}
+
+func statFile(filename string) fileStat {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return fileStat{}
+	}
+	return fileStat{
+		exists:  true,
+		size:    info.Size(),
+		modTime: info.ModTime(),
+	}
+}
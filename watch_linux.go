@@ -0,0 +1,55 @@
+package yfig
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// 监听文件所在目录（编辑器通常通过重命名替换文件），文件发生变化时向返回的channel发送通知
+func notifyFiles(files []string) (<-chan struct{}, func(), error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, nil, err
+	}
+	watched := map[string]bool{}
+	wdDirs := map[int32]string{}
+	for _, f := range files {
+		watched[f] = true
+		wd, err := syscall.InotifyAddWatch(fd, filepath.Dir(f), syscall.IN_MODIFY|syscall.IN_CLOSE_WRITE|
+			syscall.IN_CREATE|syscall.IN_DELETE|syscall.IN_MOVED_TO|syscall.IN_MOVED_FROM)
+		if err != nil {
+			syscall.Close(fd)
+			return nil, nil, err
+		}
+		wdDirs[int32(wd)] = filepath.Dir(f)
+	}
+
+	file := os.NewFile(uintptr(fd), "inotify")
+	ch := make(chan struct{}, 1)
+	go func() {
+		buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+		for {
+			n, err := file.Read(buf)
+			if err != nil {
+				return
+			}
+			offset := 0
+			for offset+syscall.SizeofInotifyEvent <= n {
+				e := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+				nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(e.Len)]
+				offset += syscall.SizeofInotifyEvent + int(e.Len)
+				name := string(bytes.TrimRight(nameBytes, "\x00"))
+				if watched[filepath.Join(wdDirs[e.Wd], name)] {
+					select {
+					case ch <- struct{}{}:
+					default:
+					}
+				}
+			}
+		}
+	}()
+	return ch, func() { file.Close() }, nil
+}
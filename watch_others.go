@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package yfig
+
+import "errors"
+
+func notifyFiles(files []string) (<-chan struct{}, func(), error) {
+	return nil, nil, errors.New("file notification is not supported on this platform")
+}
@@ -0,0 +1,305 @@
+package yfig
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// 保留source中mapping的key顺序（新增的key按名称排序追加在后）以及block格式中mapping key上的注释，
+// 列表元素内、多行字符串内及flow格式中的注释不保留；source不是YAML mapping时与Serialize相同
+func (v *YamlLoader) SerializeDocument(source []byte, value interface{}) (string, error) {
+	var orig yaml.MapSlice
+	if err := yaml.Unmarshal(source, &orig); err != nil {
+		return v.Serialize(value)
+	}
+	b, err := yaml.Marshal(orderLike(value, orig))
+	if err != nil {
+		return "", err
+	}
+	return collectYamlComments(string(source)).apply(string(b)), nil
+}
+
+// 按orig中的顺序将v转换为yaml.MapSlice
+func orderLike(v interface{}, orig interface{}) interface{} {
+	switch o := walkable(v).(type) {
+	case map[string]interface{}:
+		ret := make(yaml.MapSlice, 0, len(o))
+		seen := map[string]bool{}
+		if om, ok := orig.(yaml.MapSlice); ok {
+			for _, item := range om {
+				k := fmt.Sprint(item.Key)
+				if e, ok := o[k]; ok && !seen[k] {
+					seen[k] = true
+					ret = append(ret, yaml.MapItem{Key: k, Value: orderLike(e, item.Value)})
+				}
+			}
+		}
+		for _, k := range sortedKeys(o) {
+			if !seen[k] {
+				ret = append(ret, yaml.MapItem{Key: k, Value: orderLike(o[k], nil)})
+			}
+		}
+		return ret
+	case []interface{}:
+		ol, _ := orig.([]interface{})
+		ret := make([]interface{}, len(o))
+		for i := range o {
+			var e interface{}
+			if i < len(ol) {
+				e = ol[i]
+			}
+			ret[i] = orderLike(o[i], e)
+		}
+		return ret
+	case float64:
+		// 与Serialize一致，整数值不使用科学计数法
+		if o == math.Trunc(o) && math.Abs(o) < 1<<53 {
+			return int64(o)
+		}
+	}
+	return v
+}
+
+type yamlComment struct {
+	// key之前的注释及空行
+	before []string
+	// key所在行的注释
+	inline string
+}
+
+type yamlComments struct {
+	// 第一个key之前的内容
+	header []string
+	keys   map[string]*yamlComment
+	// 最后一个key之后的注释
+	footer []string
+}
+
+func collectYamlComments(source string) *yamlComments {
+	ret := &yamlComments{keys: map[string]*yamlComment{}}
+	t := newYamlKeyTracker()
+	var pending []string
+	first := true
+	for _, line := range strings.Split(source, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			pending = append(pending, trimmed)
+			continue
+		}
+		path, rest, ok := t.next(line)
+		if !ok {
+			if first {
+				ret.header = append(ret.header, pending...)
+			}
+			pending = nil
+			continue
+		}
+		if first {
+			ret.header = append(ret.header, pending...)
+			pending = nil
+			first = false
+		}
+		_, comment := splitYamlComment(rest)
+		if len(pending) > 0 || comment != "" {
+			if _, ok := ret.keys[path]; !ok {
+				ret.keys[path] = &yamlComment{before: pending, inline: comment}
+			}
+		}
+		pending = nil
+	}
+	for len(pending) > 0 && pending[len(pending)-1] == "" {
+		pending = pending[:len(pending)-1]
+	}
+	if first {
+		ret.header = append(ret.header, pending...)
+	} else {
+		ret.footer = pending
+	}
+	return ret
+}
+
+// 将注释插入到Marshal的结果中
+func (c *yamlComments) apply(out string) string {
+	buf := strings.Builder{}
+	for _, s := range c.header {
+		buf.WriteString(s)
+		buf.WriteByte('\n')
+	}
+	t := newYamlKeyTracker()
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	for _, line := range lines {
+		path, _, ok := t.next(line)
+		comment := c.keys[path]
+		if !ok || comment == nil {
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " "))]
+		for _, s := range comment.before {
+			if s != "" {
+				buf.WriteString(indent)
+				buf.WriteString(s)
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(line)
+		if comment.inline != "" {
+			buf.WriteByte(' ')
+			buf.WriteString(comment.inline)
+		}
+		buf.WriteByte('\n')
+	}
+	for _, s := range c.footer {
+		buf.WriteString(s)
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+type yamlKey struct {
+	indent int
+	path   string
+}
+
+// 按缩进跟踪block格式YAML中mapping key的路径，跳过列表及多行字符串的内容
+type yamlKeyTracker struct {
+	stack []yamlKey
+	// 不小于0时跳过缩进大于该值的行
+	skip int
+	// 跳过的是列表，缩进等于skip的"-"开头的行同样跳过
+	skipSeq bool
+}
+
+func newYamlKeyTracker() *yamlKeyTracker {
+	return &yamlKeyTracker{skip: -1}
+}
+
+// param: line 一行内容，空行及注释行（如多行字符串中的空行）不影响状态
+// return: key的路径，key之后的内容，是否为mapping key
+func (t *yamlKeyTracker) next(line string) (string, string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return "", "", false
+	}
+	indent := len(line) - len(strings.TrimLeft(line, " "))
+	if t.skip >= 0 {
+		if indent > t.skip || indent == t.skip && t.skipSeq && strings.HasPrefix(trimmed, "-") {
+			return "", "", false
+		}
+		t.skip = -1
+	}
+	if trimmed == "---" || trimmed == "..." || strings.HasPrefix(trimmed, "%") {
+		t.stack = t.stack[:0]
+		return "", "", false
+	}
+	if trimmed == "-" || strings.HasPrefix(trimmed, "- ") {
+		t.skip, t.skipSeq = indent, true
+		return "", "", false
+	}
+	name, rest, ok := parseYamlKey(trimmed)
+	if !ok {
+		return "", "", false
+	}
+	for len(t.stack) > 0 && t.stack[len(t.stack)-1].indent >= indent {
+		t.stack = t.stack[:len(t.stack)-1]
+	}
+	parent := ""
+	if len(t.stack) > 0 {
+		parent = t.stack[len(t.stack)-1].path
+	}
+	path := appendSegment(parent, pathSegment{key: name})
+	t.stack = append(t.stack, yamlKey{indent: indent, path: path})
+
+	value, _ := splitYamlComment(rest)
+	if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+		t.skip, t.skipSeq = indent, false
+	}
+	return path, rest, true
+}
+
+// 解析"key: value"格式的行
+// return: key，":"之后的内容
+func parseYamlKey(s string) (string, string, bool) {
+	if s == "" {
+		return "", "", false
+	}
+	var name string
+	i := 0
+	switch s[0] {
+	case '"':
+		j := 1
+		for j < len(s) && s[j] != '"' {
+			if s[j] == '\\' {
+				j++
+			}
+			j++
+		}
+		if j >= len(s) {
+			return "", "", false
+		}
+		n, err := strconv.Unquote(s[:j+1])
+		if err != nil {
+			return "", "", false
+		}
+		name, i = n, j+1
+	case '\'':
+		j := 1
+		for j < len(s) {
+			if s[j] == '\'' {
+				if j+1 < len(s) && s[j+1] == '\'' {
+					j += 2
+					continue
+				}
+				break
+			}
+			j++
+		}
+		if j >= len(s) {
+			return "", "", false
+		}
+		name, i = strings.ReplaceAll(s[1:j], "''", "'"), j+1
+	case '{', '[', '&', '*', '!', '|', '>', '#':
+		return "", "", false
+	default:
+		for i < len(s) && !(s[i] == ':' && (i+1 == len(s) || s[i+1] == ' ')) {
+			if s[i] == '#' && i > 0 && s[i-1] == ' ' {
+				return "", "", false
+			}
+			i++
+		}
+		if i == len(s) {
+			return "", "", false
+		}
+		name = strings.TrimSpace(s[:i])
+	}
+	if i >= len(s) || s[i] != ':' || i+1 < len(s) && s[i+1] != ' ' {
+		return "", "", false
+	}
+	return name, strings.TrimSpace(s[i+1:]), true
+}
+
+// 拆分值及行尾注释，引号内的"#"不作为注释
+func splitYamlComment(s string) (string, string) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case (c == '"' || c == '\'') && (i == 0 || s[i-1] == ' '):
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return strings.TrimSpace(s[:i]), s[i:]
+		}
+	}
+	return s, ""
+}